@@ -0,0 +1,136 @@
+package crt_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestCreateBatch(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+
+	cs := make([]*Certificate, 0, 20)
+	for i := 0; i < 20; i++ {
+		cs = append(cs, NewServerCert(WithCN(fmt.Sprintf("node-%d", i))))
+	}
+	// the invalid template fails alone
+	cs = append(cs, NewServerCert(WithSerialNumber(big.NewInt(0))))
+
+	results, err := g.CreateBatch(context.Background(), cs, generator.BatchOptions{Workers: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, len(cs), len(results))
+
+	serials := make(map[string]struct{})
+	for i, v := range results[:20] {
+		assert.NoError(t, v.Err)
+		parsed, err := generator.ParseCertificate(v.Cert)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("node-%d", i), parsed.Subject.CommonName)
+		serials[parsed.SerialNumber.String()] = struct{}{}
+	}
+	assert.Equal(t, 20, len(serials))
+	assert.EqualError(t, results[20].Err, "crt: serial number must be positive")
+
+	t.Run("should return error: context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results, err := g.CreateBatch(ctx, cs[:5], generator.BatchOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+		for _, v := range results {
+			assert.ErrorIs(t, v.Err, context.Canceled)
+			assert.Nil(t, v.Cert)
+		}
+	})
+}
+
+func TestGeneratorConcurrency(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	ca, caKey := g.CA()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, _, err := g.Create(NewClientCert())
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, err := g.CreateWithOptions(NewIntermediateCACert(), generator.CreateOptions{})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			g.SetCA(ca, caKey)
+			_, err := g.CertPool()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestIssuedLimit(t *testing.T) {
+	ca, caKey := createEcdsaGenWithCA(t).CA()
+
+	t.Run("should evict the oldest records", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(ca, caKey), generator.WithIssuedLimit(2))
+		for i := int64(1); i <= 3; i++ {
+			_, _, err := g.Create(NewClientCert(WithSerialNumber(big.NewInt(i))))
+			assert.NoError(t, err)
+		}
+		_, ok := g.Issued(big.NewInt(1))
+		assert.False(t, ok)
+		for _, serial := range []int64{2, 3} {
+			_, ok = g.Issued(big.NewInt(serial))
+			assert.True(t, ok)
+		}
+
+		_, _, err := g.Create(NewClientCert(WithSerialNumber(big.NewInt(3))))
+		assert.EqualError(t, err, "x509: serial number 3 has already been issued by the CA")
+	})
+
+	t.Run("should keep the latest 1024 records by default", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(ca, caKey),
+			generator.WithSerialNumberGenerator(NewSequentialSerialNumber(big.NewInt(1))))
+		cs := make([]*Certificate, 1025)
+		for i := range cs {
+			cs[i] = NewClientCert()
+		}
+		_, err := g.CreateBatch(context.Background(), cs, generator.BatchOptions{})
+		assert.NoError(t, err)
+
+		_, ok := g.Issued(big.NewInt(1))
+		assert.False(t, ok, "the oldest record must be evicted")
+		_, ok = g.Issued(big.NewInt(1025))
+		assert.True(t, ok)
+	})
+
+	t.Run("should record all the certificates", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(ca, caKey), generator.WithIssuedLimit(0))
+		for i := int64(1); i <= 3; i++ {
+			_, _, err := g.Create(NewClientCert(WithSerialNumber(big.NewInt(i))))
+			assert.NoError(t, err)
+		}
+		for i := int64(1); i <= 3; i++ {
+			_, ok := g.Issued(big.NewInt(i))
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("should not record the certificates", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(ca, caKey), generator.WithIssuedLimit(-1))
+		_, _, err := g.Create(NewClientCert(WithSerialNumber(big.NewInt(1))))
+		assert.NoError(t, err)
+		_, ok := g.Issued(big.NewInt(1))
+		assert.False(t, ok)
+	})
+}
@@ -1,37 +1,80 @@
 package crt
 
 import (
-	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
 	"math/big"
 	"net"
+	"net/url"
 	"os"
 	"time"
 )
 
 const (
-	_defaultCACommonName = "CRT GENERATOR CA"
-	_defaultCADuration   = time.Hour * 24 * 366 * 10
-	_defaultCertDuration = time.Hour * 24 * 365
+	_defaultCACommonName             = "CRT GENERATOR CA"
+	_defaultIntermediateCACommonName = "CRT GENERATOR INTERMEDIATE CA"
+	_defaultCADuration               = time.Hour * 24 * 366 * 10
+	_defaultCertDuration             = time.Hour * 24 * 365
 )
 
 const (
 	_caType = iota + 1
 	_clientType
 	_serverType
+	_intermediateCAType
 )
 
+// Clock provides the current time.
+type Clock interface {
+	Now() time.Time
+}
+
 // Certificate is the main structure of a Certificate.
 type Certificate struct {
-	cn            string
-	ctype         int
-	validity      time.Duration
-	keyUsage      x509.KeyUsage
-	organizations []string
-	dnsNames      []string
-	ips           []net.IP
-	extKeyUsages  []x509.ExtKeyUsage
+	cn             string
+	ctype          int
+	validity       time.Duration
+	notBefore      time.Time
+	notAfter       time.Time
+	backdate       time.Duration
+	clock          Clock
+	keyUsage       x509.KeyUsage
+	maxPathLen     int
+	maxPathLenZero bool
+	organizations  []string
+	orgUnits       []string
+	countries      []string
+	provinces      []string
+	localities     []string
+	streets        []string
+	postalCodes    []string
+	subjectSerial  string
+	extraNames     []pkix.AttributeTypeAndValue
+	dnsNames       []string
+	ips            []net.IP
+	uris           []*url.URL
+	emails         []string
+	extKeyUsages   []x509.ExtKeyUsage
+	crlDPs         []string
+	ocspServers    []string
+	issuingURLs    []string
+	policies       []Policy
+	unknownEKUs    []asn1.ObjectIdentifier
+	extensions     []pkix.Extension
+	serials        SerialNumberGenerator
+	err            error
+
+	permittedDNSDomains     []string
+	excludedDNSDomains      []string
+	permittedIPRanges       []*net.IPNet
+	excludedIPRanges        []*net.IPNet
+	permittedEmailAddresses []string
+	excludedEmailAddresses  []string
+	permittedURIDomains     []string
+	excludedURIDomains      []string
+	nameConstraintsCritical bool
 }
 
 // New create a new Certificate.
@@ -44,6 +87,7 @@ func New(opts ...Option) *Certificate {
 }
 
 // NewCACert create a new CA Certificate.
+// If no key usage is set, the default is x509.KeyUsageCertSign | x509.KeyUsageCRLSign.
 func NewCACert(opts ...Option) *Certificate {
 	defaults := []Option{
 		WithCN(_defaultCACommonName),
@@ -52,7 +96,31 @@ func NewCACert(opts ...Option) *Certificate {
 
 	merged := append(defaults, WithCAType())
 
-	return New(merged...)
+	return withDefaultCAKeyUsage(New(merged...))
+}
+
+// NewIntermediateCACert create a new Intermediate CA Certificate.
+// An Intermediate CA Certificate is signed by the current CA of the generator
+// instead of itself.
+// If no key usage is set, the default is x509.KeyUsageCertSign | x509.KeyUsageCRLSign.
+func NewIntermediateCACert(opts ...Option) *Certificate {
+	defaults := []Option{
+		WithCN(_defaultIntermediateCACommonName),
+	}
+	defaults = append(defaults, opts...)
+
+	merged := append(defaults, WithIntermediateCAType())
+
+	return withDefaultCAKeyUsage(New(merged...))
+}
+
+// withDefaultCAKeyUsage sets the default key usage of the CA certificate,
+// if the key usage is not set by WithKeyUsage.
+func withDefaultCAKeyUsage(c *Certificate) *Certificate {
+	if c.keyUsage == 0 {
+		c.keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	return c
 }
 
 // NewClientCert create a new Client Certificate.
@@ -85,24 +153,187 @@ func NewServerCert(opts ...Option) *Certificate {
 	return New(merged...)
 }
 
+// NewPeerCert create a new Peer Certificate, it can be used as both
+// a Server Certificate and a Client Certificate.
+func NewPeerCert(opts ...Option) *Certificate {
+	cn, _ := os.Hostname()
+	defaults := []Option{
+		WithCN(cn),
+		WithKeyUsage(x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment),
+	}
+	defaults = append(defaults, opts...)
+	merged := append(defaults,
+		appendExtKeyUsages(x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth))
+
+	return New(merged...)
+}
+
+// NewSVIDCert create a new X.509-SVID Certificate with the given SPIFFE ID,
+// see https://github.com/spiffe/spiffe/blob/main/standards/X509-SVID.md.
+// The SPIFFE ID is the only URI SAN of the certificate, the CommonName is
+// not required. Use ParseSPIFFEID to build a valid SPIFFE ID, an invalid
+// SPIFFE ID is reported by Template.
+func NewSVIDCert(id *url.URL, opts ...Option) *Certificate {
+	defaults := []Option{
+		WithKeyUsage(x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment),
+	}
+	defaults = append(defaults, opts...)
+	merged := append(defaults,
+		WithURIs(id),
+		appendExtKeyUsages(x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth))
+
+	c := New(merged...)
+	if err := validateSPIFFEID(id); err != nil {
+		c.uris = nil
+		c.err = err
+	}
+	return c
+}
+
+// Template generates a new x509.Certificate, the serial number is generated by
+// the SerialNumberGenerator of the certificate, by default, a random 128-bit number.
+// The validity period starts at the current time of the Clock of the certificate,
+// by default, time.Now().
+func (c *Certificate) Template() (*x509.Certificate, error) {
+	return c.TemplateAt(c.now())
+}
+
+// TemplateAt is the same as Template, but the validity period starts at the
+// given time instead of the current time. The NotBefore and NotAfter set by
+// WithNotBefore and WithNotAfter take precedence over the given time.
+func (c *Certificate) TemplateAt(now time.Time) (*x509.Certificate, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	notBefore, notAfter := c.period(now)
+	if notAfter.Before(notBefore) {
+		return nil, fmt.Errorf("crt: NotAfter %s is before NotBefore %s",
+			notAfter.Format(time.RFC3339), notBefore.Format(time.RFC3339))
+	}
+	n, err := c.serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := c.template(n, notBefore, notAfter)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
 // Gen generates a new x509.Certificate.
+//
+// Deprecated: Use Template instead, Gen ignores the errors of the serial number
+// generation, the validity period and the extensions.
+// If the SerialNumberGenerator fails, a random serial number is used.
 func (c *Certificate) Gen() *x509.Certificate {
-	subject := pkix.Name{
-		CommonName: c.cn,
+	notBefore, notAfter := c.period(c.now())
+	n, err := c.serialNumber()
+	if err != nil {
+		n, _ = NewRandomSerialNumber().Next()
 	}
-	subject.Organization = c.organizations
-	n, _ := rand.Int(rand.Reader, big.NewInt(1<<63-1)) // 9223372036854775808 - 1
+	tmpl, _ := c.template(n, notBefore, notAfter)
+	return tmpl
+}
+
+// serialNumber returns the next serial number of the SerialNumberGenerator,
+// by default, a random serial number.
+func (c *Certificate) serialNumber() (*big.Int, error) {
+	if c.serials == nil {
+		return NewRandomSerialNumber().Next()
+	}
+	return c.serials.Next()
+}
+
+// template generates a new x509.Certificate with the given serial number and validity period.
+// The x509.Certificate is returned even if the extensions cannot be marshaled, with the error.
+func (c *Certificate) template(n *big.Int, notBefore, notAfter time.Time) (*x509.Certificate, error) {
+	var err error
 	obj := &x509.Certificate{
 		SerialNumber:          n,
-		Subject:               subject,
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(c.validity),
+		Subject:               c.subject(),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
 		BasicConstraintsValid: true,
 		IsCA:                  c.IsCA(),
 		KeyUsage:              c.keyUsage,
 		ExtKeyUsage:           c.extKeyUsages,
 	}
 
+	if c.IsCA() {
+		obj.MaxPathLen = c.maxPathLen
+		obj.MaxPathLenZero = c.maxPathLenZero
+		obj.PermittedDNSDomainsCritical = c.nameConstraintsCritical
+		obj.PermittedDNSDomains = c.permittedDNSDomains
+		obj.ExcludedDNSDomains = c.excludedDNSDomains
+		obj.PermittedIPRanges = c.permittedIPRanges
+		obj.ExcludedIPRanges = c.excludedIPRanges
+		obj.PermittedEmailAddresses = c.permittedEmailAddresses
+		obj.ExcludedEmailAddresses = c.excludedEmailAddresses
+		obj.PermittedURIDomains = c.permittedURIDomains
+		obj.ExcludedURIDomains = c.excludedURIDomains
+	}
+
+	if len(c.dnsNames) > 0 {
+		obj.DNSNames = deduplicatestr(c.dnsNames)
+	}
+
+	if len(c.ips) > 0 {
+		obj.IPAddresses = deduplicateips(c.ips)
+	}
+
+	if len(c.uris) > 0 {
+		obj.URIs = deduplicateuris(c.uris)
+	}
+
+	if len(c.emails) > 0 {
+		obj.EmailAddresses = deduplicatestr(c.emails)
+	}
+
+	if len(c.crlDPs) > 0 {
+		obj.CRLDistributionPoints = deduplicatestr(c.crlDPs)
+	}
+
+	if len(c.ocspServers) > 0 {
+		obj.OCSPServer = deduplicatestr(c.ocspServers)
+	}
+
+	if len(c.issuingURLs) > 0 {
+		obj.IssuingCertificateURL = deduplicatestr(c.issuingURLs)
+	}
+
+	if len(c.unknownEKUs) > 0 {
+		obj.UnknownExtKeyUsage = c.unknownEKUs
+	}
+
+	if len(c.policies) > 0 {
+		obj.PolicyIdentifiers = make([]asn1.ObjectIdentifier, 0, len(c.policies))
+		for _, p := range c.policies {
+			obj.PolicyIdentifiers = append(obj.PolicyIdentifiers, p.OID)
+		}
+		// the extension given by WithExtensions takes precedence
+		if !hasExtension(c.extensions, oidExtensionCertificatePolicies) {
+			var ext pkix.Extension
+			if ext, err = marshalPolicies(c.policies); err == nil {
+				obj.ExtraExtensions = append(obj.ExtraExtensions, ext)
+			}
+		}
+	}
+
+	if len(c.extensions) > 0 {
+		obj.ExtraExtensions = append(obj.ExtraExtensions, c.extensions...)
+	}
+
+	return obj, err
+}
+
+// GenCSR generates a new x509.CertificateRequest, it carries the subject
+// and the Subject Alternative Names of the certificate.
+func (c *Certificate) GenCSR() *x509.CertificateRequest {
+	obj := &x509.CertificateRequest{
+		Subject: c.subject(),
+	}
+
 	if len(c.dnsNames) > 0 {
 		obj.DNSNames = deduplicatestr(c.dnsNames)
 	}
@@ -111,12 +342,25 @@ func (c *Certificate) Gen() *x509.Certificate {
 		obj.IPAddresses = deduplicateips(c.ips)
 	}
 
+	if len(c.uris) > 0 {
+		obj.URIs = deduplicateuris(c.uris)
+	}
+
+	if len(c.emails) > 0 {
+		obj.EmailAddresses = deduplicatestr(c.emails)
+	}
+
 	return obj
 }
 
 // IsCA return whether the certificate is a CA certificate.
 func (c *Certificate) IsCA() bool {
-	return c.ctype == _caType
+	return c.ctype == _caType || c.ctype == _intermediateCAType
+}
+
+// IsIntermediateCA return whether the certificate is an Intermediate CA certificate.
+func (c *Certificate) IsIntermediateCA() bool {
+	return c.ctype == _intermediateCAType
 }
 
 // IsClientCert return whether the certificate is a Client certificate.
@@ -146,6 +390,63 @@ func (c *Certificate) IsServerCert() bool {
 	return false
 }
 
+// HasClock return whether the Clock of the certificate is set by WithClock.
+func (c *Certificate) HasClock() bool {
+	return c.clock != nil
+}
+
+// HasSerialNumber return whether the serial number of the certificate is set by
+// WithSerialNumber or WithSerialNumberGenerator.
+func (c *Certificate) HasSerialNumber() bool {
+	return c.serials != nil
+}
+
+// HasSANs return whether the certificate has any Subject Alternative Name.
+func (c *Certificate) HasSANs() bool {
+	return len(c.dnsNames) > 0 || len(c.ips) > 0 || len(c.uris) > 0 || len(c.emails) > 0
+}
+
+// subject returns the pkix.Name of the certificate.
+func (c *Certificate) subject() pkix.Name {
+	subject := pkix.Name{
+		CommonName: c.cn,
+	}
+	subject.Organization = c.organizations
+	subject.OrganizationalUnit = c.orgUnits
+	subject.Country = c.countries
+	subject.Province = c.provinces
+	subject.Locality = c.localities
+	subject.StreetAddress = c.streets
+	subject.PostalCode = c.postalCodes
+	subject.SerialNumber = c.subjectSerial
+	subject.ExtraNames = c.extraNames
+	return subject
+}
+
+// now returns the current time of the Clock of the certificate.
+func (c *Certificate) now() time.Time {
+	if c.clock != nil {
+		return c.clock.Now()
+	}
+	return time.Now()
+}
+
+// period returns the validity period of the certificate issued at the given time.
+// The NotBefore is backdated by the backdate duration, the NotAfter is not.
+func (c *Certificate) period(now time.Time) (notBefore, notAfter time.Time) {
+	notBefore, notAfter = c.notBefore, c.notAfter
+	if notBefore.IsZero() {
+		notBefore = now.Add(-c.backdate)
+	}
+	if notAfter.IsZero() {
+		notAfter = now.Add(c.validity)
+		if !c.notBefore.IsZero() {
+			notAfter = c.notBefore.Add(c.validity)
+		}
+	}
+	return notBefore, notAfter
+}
+
 // withOptions set options for the Certificate
 func (c *Certificate) withOptions(opts ...Option) {
 	for _, opt := range opts {
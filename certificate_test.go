@@ -1,7 +1,9 @@
 package crt_test
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -288,7 +290,7 @@ func TestPrivateKeyWithPass(t *testing.T) {
 
 func TestPKS8PrivateKey(t *testing.T) {
 	testPass := []byte("123456")
-	t.Run("Should ignore the passphrase when creating a PKCS#8 RSA private key", func(t *testing.T) {
+	t.Run("Create encrypted PKCS#8 RSA private key with passphrase", func(t *testing.T) {
 		g := createGenWithUseAsCA(t)
 		keyg := key.NewRsaKey(0)
 		cert := NewServerCert()
@@ -297,14 +299,16 @@ func TestPKS8PrivateKey(t *testing.T) {
 			Password: testPass,
 		}})
 		assert.Nil(t, err)
+		block, _ := pem.Decode(keyRaw)
+		assert.Equal(t, key.EncryptedPKCS8BlockType, block.Type)
 
-		parsedKey, err := parseKeyBytes(keyRaw)
+		parsedKey, err := key.ParsePrivateKey(keyRaw, testPass)
 		assert.Nil(t, err)
 		_, ok := parsedKey.(*rsa.PrivateKey)
 		assert.True(t, ok)
 	})
 
-	t.Run("Should ignore the passphrase when creating a PKCS#8 ECDSA private key", func(t *testing.T) {
+	t.Run("Create encrypted PKCS#8 ECDSA private key with passphrase", func(t *testing.T) {
 		g := createGenWithUseAsCA(t)
 		keyg := key.NewEcdsaKey(nil)
 		cert := NewServerCert()
@@ -313,6 +317,21 @@ func TestPKS8PrivateKey(t *testing.T) {
 			Password: testPass,
 		}})
 		assert.Nil(t, err)
+		block, _ := pem.Decode(keyRaw)
+		assert.Equal(t, key.EncryptedPKCS8BlockType, block.Type)
+
+		parsedKey, err := key.ParsePrivateKey(keyRaw, testPass)
+		assert.Nil(t, err)
+		_, ok := parsedKey.(*ecdsa.PrivateKey)
+		assert.True(t, ok)
+	})
+
+	t.Run("Create PKCS#8 private key without passphrase", func(t *testing.T) {
+		keyg := key.NewEcdsaKey(nil)
+		signer, err := keyg.Gen()
+		assert.Nil(t, err)
+		keyRaw, err := keyg.Marshal(signer, &key.MarshalOptions{IsPKCS8: true})
+		assert.Nil(t, err)
 
 		parsedKey, err := parseKeyBytes(keyRaw)
 		assert.Nil(t, err)
@@ -321,6 +340,122 @@ func TestPKS8PrivateKey(t *testing.T) {
 	})
 }
 
+func TestEncryptedPKCS8PrivateKey(t *testing.T) {
+	testPass := []byte("123456")
+	signer, err := key.NewEcdsaKey(nil).Gen()
+	assert.Nil(t, err)
+	pkey := signer.(*ecdsa.PrivateKey)
+
+	tests := []struct {
+		title  string
+		kdf    key.KDF
+		cipher key.Cipher
+	}{
+		{"PBKDF2 AES-256-CBC", key.PBKDF2, key.AES256CBC},
+		{"PBKDF2 AES-128-CBC", key.PBKDF2, key.AES128CBC},
+		{"PBKDF2 AES-256-GCM", key.PBKDF2, key.AES256GCM},
+		{"scrypt AES-128-GCM", key.Scrypt, key.AES128GCM},
+	}
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			keyRaw, err := key.MarshalEncryptedPKCS8PrivateKey(pkey, testPass, v.kdf, v.cipher)
+			assert.Nil(t, err)
+
+			parsedKey, err := key.ParsePrivateKey(keyRaw, testPass)
+			assert.Nil(t, err)
+			assert.True(t, pkey.Equal(parsedKey))
+
+			_, err = key.ParsePrivateKey(keyRaw, []byte("654321"))
+			assert.Equal(t, key.ErrIncorrectPassword, err)
+			_, err = key.ParsePrivateKey(keyRaw, nil)
+			assert.Equal(t, key.ErrPasswordRequired, err)
+		})
+	}
+
+	t.Run("Should return error with an unsupported cipher", func(t *testing.T) {
+		_, err := key.MarshalEncryptedPKCS8PrivateKey(pkey, testPass, key.PBKDF2, key.Cipher(100))
+		assert.Equal(t, key.ErrUnsupportedEncryption, err)
+	})
+	t.Run("Should return error with the key derivation parameters out of range", func(t *testing.T) {
+		tests := []struct {
+			kdf     key.KDF
+			encoded []byte // the encoded parameter to replace
+			crafted []byte
+		}{
+			// PBKDF2 iteration count 600000 -> 8388607
+			{key.PBKDF2, []byte{0x02, 0x03, 0x09, 0x27, 0xc0}, []byte{0x02, 0x03, 0x7f, 0xff, 0xff}},
+			// scrypt N=16384, r=8 -> r=127
+			{key.Scrypt, []byte{0x02, 0x02, 0x40, 0x00, 0x02, 0x01, 0x08}, []byte{0x02, 0x02, 0x40, 0x00, 0x02, 0x01, 0x7f}},
+		}
+		for _, v := range tests {
+			keyRaw, err := key.MarshalEncryptedPKCS8PrivateKey(pkey, testPass, v.kdf, key.AES256CBC)
+			assert.Nil(t, err)
+			block, _ := pem.Decode(keyRaw)
+			assert.Equal(t, 1, bytes.Count(block.Bytes, v.encoded))
+			block.Bytes = bytes.Replace(block.Bytes, v.encoded, v.crafted, 1)
+
+			_, err = key.ParsePrivateKey(pem.EncodeToMemory(block), testPass)
+			assert.ErrorIs(t, err, key.ErrUnsupportedEncryption)
+		}
+	})
+}
+
+func TestLegacyEncryptedPEMPrivateKey(t *testing.T) {
+	keyg := key.NewEcdsaKey(nil)
+	signer, err := keyg.Gen()
+	assert.Nil(t, err)
+	keyRaw, err := keyg.Marshal(signer, &key.MarshalOptions{Password: []byte("123456")})
+	assert.Nil(t, err)
+
+	parsedKey, err := key.ParsePrivateKey(keyRaw, []byte("123456"))
+	assert.Nil(t, err)
+	assert.True(t, signer.(*ecdsa.PrivateKey).Equal(parsedKey))
+
+	for _, password := range []string{"654321", "foo", "bar", "baz"} {
+		_, err = key.ParsePrivateKey(keyRaw, []byte(password))
+		assert.Equal(t, key.ErrIncorrectPassword, err)
+	}
+	_, err = key.ParsePrivateKey(keyRaw, nil)
+	assert.Equal(t, key.ErrPasswordRequired, err)
+}
+
+func TestEd25519PrivateKey(t *testing.T) {
+	t.Run("Create Ed25519 CA and server certificate", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEd25519Key()))
+		caRaw, _, err := g.CreateWithOptions(NewCACert(), generator.CreateOptions{UseAsCA: true})
+		assert.Nil(t, err)
+		ca, err := parseCertBytes(caRaw)
+		assert.Nil(t, err)
+		assert.Equal(t, x509.Ed25519, ca.PublicKeyAlgorithm)
+
+		certRaw, keyRaw, err := g.Create(NewServerCert())
+		assert.Nil(t, err)
+		parsedCert, err := parseCertBytes(certRaw)
+		assert.Nil(t, err)
+		assert.Nil(t, parsedCert.CheckSignatureFrom(ca))
+		assert.Equal(t, x509.PureEd25519, parsedCert.SignatureAlgorithm)
+
+		parsedKey, err := parseKeyBytes(keyRaw)
+		assert.Nil(t, err)
+		_, ok := parsedKey.(ed25519.PrivateKey)
+		assert.True(t, ok)
+	})
+
+	t.Run("Create encrypted Ed25519 private key with passphrase", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		_, keyRaw, err := g.CreateWithOptions(NewServerCert(), generator.CreateOptions{
+			G:       key.NewEd25519Key(),
+			KeyOpts: &key.MarshalOptions{Password: []byte("123456")},
+		})
+		assert.Nil(t, err)
+
+		parsedKey, err := key.ParsePrivateKey(keyRaw, []byte("123456"))
+		assert.Nil(t, err)
+		_, ok := parsedKey.(ed25519.PrivateKey)
+		assert.True(t, ok)
+	})
+}
+
 func decryptAndEncode(t *testing.T, b, pass []byte, blockType string) []byte {
 	t.Helper()
 	block, _ := pem.Decode(b)
@@ -350,3 +485,28 @@ func reset() {
 	_ = cleanfiles(filelist)
 	filelist = []string{}
 }
+
+func TestNewPeerCert(t *testing.T) {
+	cert := NewPeerCert()
+	assert.True(t, cert.IsServerCert())
+	assert.True(t, cert.IsClientCert())
+	assert.False(t, cert.IsCA())
+
+	x509crt, err := cert.Template()
+	assert.NoError(t, err)
+	assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, x509crt.KeyUsage)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, x509crt.ExtKeyUsage)
+}
+
+func TestCAKeyUsage(t *testing.T) {
+	assert.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, mustTemplate(t, NewCACert()).KeyUsage)
+	assert.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageCRLSign, mustTemplate(t, NewIntermediateCACert()).KeyUsage)
+
+	// the default is not applied if the key usage is set
+	tmpl, err := NewCACert(WithKeyUsage(x509.KeyUsageCertSign)).Template()
+	assert.NoError(t, err)
+	assert.Equal(t, x509.KeyUsageCertSign, tmpl.KeyUsage)
+	tmpl, err = NewIntermediateCACert(WithKeyUsage(x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature)).Template()
+	assert.NoError(t, err)
+	assert.Equal(t, x509.KeyUsageCertSign|x509.KeyUsageDigitalSignature, tmpl.KeyUsage)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shipengqi/crt/profile"
+)
+
+const (
+	cmdCA      = "ca"
+	cmdServer  = "server"
+	cmdClient  = "client"
+	cmdPeer    = "peer"
+	cmdSign    = "sign"
+	cmdInspect = "inspect"
+)
+
+// listFlag is a repeatable flag, each value can also be a comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// intFlag is an optional int flag.
+type intFlag struct {
+	v   int
+	set bool
+}
+
+func (f *intFlag) String() string {
+	if !f.set {
+		return ""
+	}
+	return strconv.Itoa(f.v)
+}
+
+func (f *intFlag) Set(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	f.v = n
+	f.set = true
+	return nil
+}
+
+// certFlags defines the flags of the certificate template.
+type certFlags struct {
+	profileFile  string
+	profileName  string
+	cn           string
+	dn           string
+	orgs         listFlag
+	orgUnits     listFlag
+	dnsNames     listFlag
+	ips          listFlag
+	uris         listFlag
+	emails       listFlag
+	validity     string
+	keyUsages    listFlag
+	extKeyUsages listFlag
+}
+
+func (f *certFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.profileFile, "profile-file", "", "JSON or YAML file of the certificate profiles")
+	fs.StringVar(&f.profileName, "profile", "", "name of the certificate profile, default is the command name")
+	fs.StringVar(&f.cn, "cn", "", "CommonName of the certificate")
+	fs.StringVar(&f.dn, "subject", "", "subject distinguished name, e.g. \"CN=foo,O=bar,C=US\"")
+	fs.Var(&f.orgs, "org", "Organization of the certificate, repeatable or comma-separated")
+	fs.Var(&f.orgUnits, "ou", "OrganizationalUnit of the certificate, repeatable or comma-separated")
+	fs.Var(&f.dnsNames, "dns", "DNS name SAN, repeatable or comma-separated")
+	fs.Var(&f.ips, "ip", "IP address SAN, repeatable or comma-separated")
+	fs.Var(&f.uris, "uri", "URI SAN, repeatable or comma-separated")
+	fs.Var(&f.emails, "email", "email address SAN, repeatable or comma-separated")
+	fs.StringVar(&f.validity, "validity", "", "validity of the certificate, e.g. \"720h\" or \"365d\"")
+	fs.Var(&f.keyUsages, "key-usage", "key usage, e.g. \"digital_signature\", repeatable or comma-separated")
+	fs.Var(&f.extKeyUsages, "ext-key-usage", "extended key usage, e.g. \"server_auth\", repeatable or comma-separated")
+}
+
+// profile returns the profile of the flags, the flags override the fields of
+// the profile loaded from the profile file, or the built-in profile.
+func (f *certFlags) profile(name string) (*profile.Profile, error) {
+	if len(f.profileName) > 0 {
+		name = f.profileName
+	}
+	ps := profile.Builtin()
+	if len(f.profileFile) > 0 {
+		var err error
+		if ps, err = profile.Load(f.profileFile); err != nil {
+			return nil, err
+		}
+	}
+	p := &profile.Profile{}
+	if len(name) > 0 {
+		var err error
+		if p, err = ps.Get(name); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(f.dn) > 0 {
+		p.Subject.DN = f.dn
+	}
+	if len(f.cn) > 0 {
+		p.Subject.CommonName = f.cn
+	}
+	if len(f.orgs) > 0 {
+		p.Subject.Organizations = f.orgs
+	}
+	if len(f.orgUnits) > 0 {
+		p.Subject.OrganizationalUnits = f.orgUnits
+	}
+	if len(f.dnsNames) > 0 {
+		p.DNSNames = f.dnsNames
+	}
+	if len(f.ips) > 0 {
+		p.IPs = f.ips
+	}
+	if len(f.uris) > 0 {
+		p.URIs = f.uris
+	}
+	if len(f.emails) > 0 {
+		p.Emails = f.emails
+	}
+	if len(f.validity) > 0 {
+		p.Validity = f.validity
+	}
+	if len(f.keyUsages) > 0 {
+		p.KeyUsages = f.keyUsages
+	}
+	if len(f.extKeyUsages) > 0 {
+		p.ExtKeyUsages = f.extKeyUsages
+	}
+	return p, nil
+}
+
+// keyFlags defines the flags of the private key.
+type keyFlags struct {
+	keyType     string
+	keySize     int
+	curve       string
+	keyPassword string
+	pkcs8       bool
+}
+
+func (f *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.keyType, "key-type", "", "type of the private key: rsa, ecdsa or ed25519, default is rsa")
+	fs.IntVar(&f.keySize, "key-size", 0, "size of the RSA private key, default is 4096")
+	fs.StringVar(&f.curve, "curve", "", "curve of the ECDSA private key: P-224, P-256, P-384 or P-521, default is P-256")
+	fs.StringVar(&f.keyPassword, "key-password", "", "password to encrypt the private key, the key is encrypted in PKCS #8")
+	fs.BoolVar(&f.pkcs8, "pkcs8", false, "encode the private key in PKCS #8")
+}
+
+func (f *keyFlags) apply(p *profile.Profile) {
+	if len(f.keyType) > 0 {
+		p.Key.Algorithm = f.keyType
+	}
+	if f.keySize > 0 {
+		p.Key.Size = f.keySize
+	}
+	if len(f.curve) > 0 {
+		p.Key.Curve = f.curve
+	}
+}
+
+// caFlags defines the flags of the CA pair.
+type caFlags struct {
+	caCert     string
+	caKey      string
+	caPassword string
+}
+
+func (f *caFlags) register(fs *flag.FlagSet, defaultCert, defaultKey string) {
+	fs.StringVar(&f.caCert, "ca-cert", defaultCert, "CA certificate file")
+	fs.StringVar(&f.caKey, "ca-key", defaultKey, "CA private key file")
+	fs.StringVar(&f.caPassword, "ca-password", "", "password of the CA private key")
+}
+
+// issuer returns the profile.Issuer of the flags, it returns nil if neither
+// the CA certificate nor private key file is given, and an error if only one
+// of them is given.
+func (f *caFlags) issuer() (*profile.Issuer, error) {
+	if len(f.caCert) == 0 && len(f.caKey) == 0 {
+		return nil, nil
+	}
+	if len(f.caCert) == 0 || len(f.caKey) == 0 {
+		return nil, errors.New("-ca-cert and -ca-key must be given together")
+	}
+	return &profile.Issuer{
+		CertFile: f.caCert,
+		KeyFile:  f.caKey,
+		Password: f.caPassword,
+	}, nil
+}
+
+// resolve returns the CA pair to use, the given profile.Issuer is used
+// unless the CA flags are given explicitly, -ca-password given on its own
+// is applied to a copy of the profile.Issuer.
+func (f *caFlags) resolve(fs *flag.FlagSet, issuer *profile.Issuer) (*profile.Issuer, error) {
+	if issuer == nil || isFlagSet(fs, "ca-cert", "ca-key") {
+		return f.issuer()
+	}
+	if isFlagSet(fs, "ca-password") {
+		copied := *issuer
+		copied.Password = f.caPassword
+		issuer = &copied
+	}
+	return issuer, nil
+}
+
+// isFlagSet reports whether any of the given flags is set on the command line.
+func isFlagSet(fs *flag.FlagSet, names ...string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range names {
+			if f.Name == name {
+				set = true
+			}
+		}
+	})
+	return set
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("crt "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
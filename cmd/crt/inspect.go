@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shipengqi/crt/inspect"
+)
+
+// runInspect runs the inspect command.
+func runInspect(args []string, stdout, stderr io.Writer) error {
+	var asJSON bool
+	fs := newFlagSet(cmdInspect, stderr)
+	fs.BoolVar(&asJSON, "json", false, "print in JSON form")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crt inspect [-json] <file>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no file is given")
+	}
+
+	var infos []*inspect.Info
+	for _, fpath := range fs.Args() {
+		data, err := os.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		parsed, err := inspect.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fpath, err)
+		}
+		infos = append(infos, parsed...)
+	}
+	if asJSON {
+		return inspect.WriteJSON(stdout, infos...)
+	}
+	return inspect.WriteText(stdout, infos...)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+	"github.com/shipengqi/crt/profile"
+)
+
+// runIssue runs the ca, server, client and peer commands.
+func runIssue(name string, args []string, stdout, stderr io.Writer) error {
+	var (
+		cf         certFlags
+		kf         keyFlags
+		caf        caFlags
+		out        string
+		keyOut     string
+		appendCA   bool
+		force      bool
+		maxPathLen intFlag
+	)
+	fs := newFlagSet(name, stderr)
+	cf.register(fs)
+	kf.register(fs)
+	if name == cmdCA {
+		caf.register(fs, "", "")
+		fs.Var(&maxPathLen, "max-path-len", "maximum number of intermediate CAs that may follow the CA, negative means unlimited")
+	} else {
+		caf.register(fs, "ca.crt", "ca.key")
+	}
+	fs.StringVar(&out, "out", name+".crt", "output certificate file")
+	fs.StringVar(&keyOut, "key-out", name+".key", "output private key file")
+	fs.BoolVar(&appendCA, "append-ca", false, "append the CA certificate to the output certificate file")
+	fs.BoolVar(&force, "force", false, "overwrite the existing output files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := cf.profile(name)
+	if err != nil {
+		return err
+	}
+	kf.apply(p)
+	if p.Issuer, err = caf.resolve(fs, p.Issuer); err != nil {
+		return err
+	}
+	if name == cmdCA && p.Issuer != nil && p.Type == profile.TypeCA {
+		p.Type = profile.TypeIntermediate
+	}
+	if name != cmdCA && p.Issuer == nil {
+		return errors.New("-ca-cert and -ca-key are required")
+	}
+	if maxPathLen.set {
+		p.MaxPathLen = &maxPathLen.v
+	}
+	if !force {
+		if err = checkOutputs(out, keyOut); err != nil {
+			return err
+		}
+	}
+
+	g, err := p.Generator()
+	if err != nil {
+		return err
+	}
+	c, err := p.Certificate()
+	if err != nil {
+		return err
+	}
+	opts := generator.CreateOptions{
+		KeyOpts:  &key.MarshalOptions{IsPKCS8: kf.pkcs8},
+		AppendCA: appendCA,
+	}
+	if len(kf.keyPassword) > 0 {
+		opts.KeyOpts.Password = []byte(kf.keyPassword)
+		opts.KeyOpts.IsPKCS8 = true
+	}
+	cert, pkey, err := g.CreateWithOptions(c, opts)
+	if err != nil {
+		return err
+	}
+
+	w, err := newFileWriter(out, keyOut, force)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+	if err = w.Write(cert, pkey); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "created %s and %s\n", out, keyOut)
+	return nil
+}
+
+// newFileWriter returns a generator.FileWriter of the given paths,
+// the private key file is only accessible by the owner.
+// The existing files are only overwritten if force is true.
+func newFileWriter(certfile, keyfile string, force bool) (*generator.FileWriter, error) {
+	certf, err := os.OpenFile(certfile, outputFlag(force), 0o644)
+	if err != nil {
+		return nil, err
+	}
+	keyf, err := os.OpenFile(keyfile, outputFlag(force), 0o600)
+	if err != nil {
+		// remove the certificate file just created, so it does not block the next run
+		_ = certf.Close()
+		_ = os.Remove(certfile)
+		return nil, err
+	}
+	return generator.NewFileWriter(certf, keyf), nil
+}
+
+// checkOutputs returns an error if any of the given output files exists.
+func checkOutputs(paths ...string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputFlag returns the flag to open an output file, the existing file is
+// truncated if force is true, otherwise the file must not exist.
+func outputFlag(force bool) int {
+	if force {
+		return os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	return os.O_WRONLY | os.O_CREATE | os.O_EXCL
+}
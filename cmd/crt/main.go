@@ -0,0 +1,73 @@
+// Command crt creates CA, server, client and peer certificates, signs
+// certificate signing requests and inspects certificates.
+//
+// Usage:
+//
+//	crt <command> [flags]
+//
+// The commands are:
+//
+//	ca       create a root CA, or an intermediate CA if -ca-cert and -ca-key are given
+//	server   issue a server certificate from an existing CA
+//	client   issue a client certificate from an existing CA
+//	peer     issue a peer (server and client) certificate from an existing CA
+//	sign     sign a certificate signing request with an existing CA
+//	inspect  print certificates, CSRs and CRLs
+//
+// Run "crt <command> -h" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: crt <command> [flags]
+
+Commands:
+  ca       create a root CA, or an intermediate CA if -ca-cert and -ca-key are given
+  server   issue a server certificate from an existing CA
+  client   issue a client certificate from an existing CA
+  peer     issue a peer (server and client) certificate from an existing CA
+  sign     sign a certificate signing request with an existing CA
+  inspect  print certificates, CSRs and CRLs
+
+Run "crt <command> -h" for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "crt:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("no command is given")
+	}
+
+	var err error
+	switch args[0] {
+	case cmdCA, cmdServer, cmdClient, cmdPeer:
+		err = runIssue(args[0], args[1:], stdout, stderr)
+	case cmdSign:
+		err = runSign(args[1:], stdout, stderr)
+	case cmdInspect:
+		err = runInspect(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	ca := []string{"-ca-cert", path("ca.crt"), "-ca-key", path("ca.key")}
+
+	runOK := func(t *testing.T, args ...string) string {
+		var stdout, stderr bytes.Buffer
+		err := run(args, &stdout, &stderr)
+		assert.NoError(t, err, stderr.String())
+		return stdout.String()
+	}
+	parse := func(t *testing.T, name string) []*x509.Certificate {
+		data, err := os.ReadFile(path(name))
+		assert.NoError(t, err)
+		certs, err := generator.ParseCertificates(data)
+		assert.NoError(t, err)
+		return certs
+	}
+
+	t.Run("ca", func(t *testing.T) {
+		out := runOK(t, "ca", "-cn", "Test Root", "-key-type", "ecdsa", "-max-path-len", "1",
+			"-out", path("ca.crt"), "-key-out", path("ca.key"))
+		assert.Contains(t, out, "ca.crt")
+		cert := parse(t, "ca.crt")[0]
+		assert.True(t, cert.IsCA)
+		assert.Equal(t, "Test Root", cert.Subject.CommonName)
+		assert.Equal(t, 1, cert.MaxPathLen)
+
+		info, err := os.Stat(path("ca.key"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("intermediate", func(t *testing.T) {
+		args := append([]string{"ca", "-key-type", "ecdsa", "-append-ca",
+			"-out", path("inter.crt"), "-key-out", path("inter.key")}, ca...)
+		runOK(t, args...)
+		certs := parse(t, "inter.crt")
+		assert.Equal(t, 2, len(certs))
+		assert.True(t, certs[0].IsCA)
+		assert.Equal(t, "Test Root", certs[0].Issuer.CommonName)
+	})
+
+	t.Run("server", func(t *testing.T) {
+		args := append([]string{"server", "-cn", "example.com", "-dns", "example.com,www.example.com",
+			"-ip", "127.0.0.1", "-validity", "30d", "-key-type", "ed25519", "-key-password", "secret",
+			"-out", path("server.crt"), "-key-out", path("server.key")}, ca...)
+		runOK(t, args...)
+		cert := parse(t, "server.crt")[0]
+		assert.Equal(t, []string{"example.com", "www.example.com"}, cert.DNSNames)
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+
+		_, err := key.ParsePrivateKeyFile(path("server.key"), []byte("secret"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("client and peer", func(t *testing.T) {
+		for _, name := range []string{"client", "peer"} {
+			args := append([]string{name, "-key-type", "ecdsa",
+				"-out", path(name + ".crt"), "-key-out", path(name + ".key")}, ca...)
+			runOK(t, args...)
+			cert := parse(t, name+".crt")[0]
+			assert.Contains(t, cert.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		}
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		signer, err := key.NewEcdsaKey(nil).Gen()
+		assert.NoError(t, err)
+		csr, err := generator.CreateCSR(crt.New(crt.WithCN("csr.example.com"), crt.WithDNSNames("csr.example.com")), signer)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(path("req.csr"), csr, 0o600))
+
+		args := append([]string{"sign", "-csr", path("req.csr"), "-profile", "server"}, ca...)
+		runOK(t, args...)
+		cert := parse(t, "req.crt")[0]
+		assert.Equal(t, "csr.example.com", cert.Subject.CommonName)
+		assert.Equal(t, []string{"csr.example.com"}, cert.DNSNames)
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+	})
+
+	t.Run("inspect", func(t *testing.T) {
+		out := runOK(t, "inspect", path("server.crt"), path("req.csr"))
+		assert.Contains(t, out, "CN=example.com")
+		assert.Contains(t, out, "www.example.com")
+		assert.Contains(t, out, "Certificate request:")
+
+		out = runOK(t, "inspect", "-json", path("server.crt"))
+		assert.Contains(t, out, `"dns_names": [`)
+	})
+
+	t.Run("force", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"client", "-key-type", "ecdsa",
+			"-out", path("client.crt"), "-key-out", path("client.key")}, ca...)
+		before := parse(t, "client.crt")[0]
+		err := run(args, &stdout, &stderr)
+		assert.ErrorContains(t, err, "client.crt already exists, use -force to overwrite it")
+		assert.Equal(t, before.Raw, parse(t, "client.crt")[0].Raw)
+
+		err = run(append([]string{"sign", "-csr", path("req.csr")}, ca...), &stdout, &stderr)
+		assert.ErrorContains(t, err, "req.crt already exists")
+
+		runOK(t, append(args, "-force")...)
+		assert.NotEqual(t, before.Raw, parse(t, "client.crt")[0].Raw)
+	})
+
+	t.Run("profile file", func(t *testing.T) {
+		profiles := `{"profiles": {"web": {"type": "server", "subject": {"common_name": "web"}, "key": {"algorithm": "ecdsa"}}}}`
+		assert.NoError(t, os.WriteFile(path("profiles.json"), []byte(profiles), 0o600))
+		args := append([]string{"server", "-profile-file", path("profiles.json"), "-profile", "web",
+			"-out", path("web.crt"), "-key-out", path("web.key")}, ca...)
+		runOK(t, args...)
+		assert.Equal(t, "web", parse(t, "web.crt")[0].Subject.CommonName)
+	})
+
+	t.Run("profile issuer with -ca-password", func(t *testing.T) {
+		runOK(t, "ca", "-key-type", "ecdsa", "-key-password", "capass",
+			"-out", path("enc-ca.crt"), "-key-out", path("enc-ca.key"))
+		profiles := `{"profiles": {"web": {"type": "server", "key": {"algorithm": "ecdsa"},` +
+			`"issuer": {"cert_file": "` + path("enc-ca.crt") + `", "key_file": "` + path("enc-ca.key") + `"}}}}`
+		assert.NoError(t, os.WriteFile(path("issuer.json"), []byte(profiles), 0o600))
+
+		var stdout, stderr bytes.Buffer
+		args := []string{"server", "-profile-file", path("issuer.json"), "-profile", "web",
+			"-out", path("enc.crt"), "-key-out", path("enc.key")}
+		assert.Error(t, run(args, &stdout, &stderr))
+		runOK(t, append(args, "-ca-password", "capass")...)
+		assert.Equal(t, "CRT GENERATOR CA", parse(t, "enc.crt")[0].Issuer.CommonName)
+
+		runOK(t, "sign", "-csr", path("req.csr"), "-out", path("enc-req.crt"),
+			"-profile-file", path("issuer.json"), "-profile", "web", "-ca-password", "capass")
+		assert.Equal(t, "csr.example.com", parse(t, "enc-req.crt")[0].Subject.CommonName)
+	})
+
+	t.Run("should remove the certificate file: key file cannot be created", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"client", "-key-type", "ecdsa",
+			"-out", path("orphan.crt"), "-key-out", path(filepath.Join("none", "orphan.key"))}, ca...)
+		assert.Error(t, run(args, &stdout, &stderr))
+		_, err := os.Stat(path("orphan.crt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		title    string
+		args     []string
+		expected string
+	}{
+		{"no command", nil, "no command is given"},
+		{"unknown command", []string{"foo"}, "unknown command \"foo\""},
+		{"CA not found", []string{"server", "-ca-cert", filepath.Join(dir, "none.crt")}, "no such file or directory"},
+		{"CSR is required", []string{"sign"}, "-csr is required"},
+		{"CA key is not given", []string{"ca", "-ca-cert", filepath.Join(dir, "ca.crt")}, "-ca-cert and -ca-key must be given together"},
+		{"CA cert is not given", []string{"server", "-ca-cert", ""}, "-ca-cert and -ca-key must be given together"},
+		{"no file to inspect", []string{"inspect"}, "no file is given"},
+	}
+	for _, v := range tests {
+		t.Run("should return error: "+v.title, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(v.args, &stdout, &stderr)
+			assert.ErrorContains(t, err, v.expected)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.NoError(t, run([]string{"server", "-h"}, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), "-dns")
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shipengqi/crt/generator"
+)
+
+// runSign runs the sign command.
+func runSign(args []string, stdout, stderr io.Writer) error {
+	var (
+		cf    certFlags
+		caf   caFlags
+		csr   string
+		out   string
+		force bool
+	)
+	fs := newFlagSet(cmdSign, stderr)
+	cf.register(fs)
+	caf.register(fs, "ca.crt", "ca.key")
+	fs.StringVar(&csr, "csr", "", "certificate signing request file")
+	fs.StringVar(&out, "out", "", "output certificate file, default is the CSR file with the .crt extension")
+	fs.BoolVar(&force, "force", false, "overwrite the existing output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(csr) == 0 {
+		return errors.New("-csr is required")
+	}
+	if len(out) == 0 {
+		out = strings.TrimSuffix(csr, filepath.Ext(csr)) + ".crt"
+	}
+	if !force {
+		if err := checkOutputs(out); err != nil {
+			return err
+		}
+	}
+
+	// the subject and public key are taken from the CSR, so there is no default profile
+	p, err := cf.profile("")
+	if err != nil {
+		return err
+	}
+	c, err := p.Certificate()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(csr)
+	if err != nil {
+		return err
+	}
+	issuer, err := caf.resolve(fs, p.Issuer)
+	if err != nil {
+		return err
+	}
+	if issuer == nil {
+		return errors.New("-ca-cert and -ca-key are required")
+	}
+	var password []byte
+	if len(issuer.Password) > 0 {
+		password = []byte(issuer.Password)
+	}
+	g, err := generator.NewFromCAFiles(issuer.CertFile, issuer.KeyFile, password)
+	if err != nil {
+		return err
+	}
+	cert, err := g.SignCSR(data, c)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(out, outputFlag(force), 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(cert)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "created %s\n", out)
+	return nil
+}
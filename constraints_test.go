@@ -0,0 +1,111 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+)
+
+func TestNameConstraints(t *testing.T) {
+	_, permittedNet, _ := net.ParseCIDR("10.0.0.0/8")
+	_, excludedNet, _ := net.ParseCIDR("10.1.0.0/16")
+
+	g := createEcdsaGenWithCA(t)
+	root, _ := g.CA()
+	interRaw, _, err := g.CreateWithOptions(NewIntermediateCACert(
+		WithPermittedDNSDomains("tenant.example.com"),
+		WithExcludedDNSDomains("secret.tenant.example.com"),
+		WithPermittedIPRanges(permittedNet),
+		WithExcludedIPRanges(excludedNet),
+		WithPermittedEmailAddresses("tenant.example.com"),
+		WithPermittedURIDomains(".tenant.example.com"),
+		WithNameConstraintsCritical(),
+	), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+
+	inter, err := parseCertBytes(interRaw)
+	assert.NoError(t, err)
+	assert.True(t, inter.PermittedDNSDomainsCritical)
+	assert.Equal(t, []string{"tenant.example.com"}, inter.PermittedDNSDomains)
+	assert.Equal(t, []string{"secret.tenant.example.com"}, inter.ExcludedDNSDomains)
+	assert.Equal(t, "10.0.0.0/8", inter.PermittedIPRanges[0].String())
+	assert.Equal(t, "10.1.0.0/16", inter.ExcludedIPRanges[0].String())
+	assert.Equal(t, []string{"tenant.example.com"}, inter.PermittedEmailAddresses)
+	assert.Equal(t, []string{".tenant.example.com"}, inter.PermittedURIDomains)
+
+	t.Run("permitted", func(t *testing.T) {
+		u, _ := url.Parse("spiffe://web.tenant.example.com/api")
+		leafRaw, _, err := g.Create(NewServerCert(
+			WithDNSNames("api.tenant.example.com"),
+			WithIPs(net.ParseIP("10.2.0.1")),
+			WithEmailAddresses("admin@tenant.example.com"),
+			WithURIs(u),
+		))
+		assert.NoError(t, err)
+		leaf, err := parseCertBytes(leafRaw)
+		assert.NoError(t, err)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		intermediates := x509.NewCertPool()
+		intermediates.AddCert(inter)
+		_, err = leaf.Verify(x509.VerifyOptions{
+			DNSName:       "api.tenant.example.com",
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		assert.NoError(t, err)
+	})
+
+	u, _ := url.Parse("https://tenant.example.com/api")
+	tests := []struct {
+		title    string
+		cert     *Certificate
+		expected string
+	}{
+		{
+			"DNS name not permitted",
+			NewServerCert(WithDNSNames("api.example.com")),
+			"x509: DNS name \"api.example.com\" is not permitted by the CA name constraints",
+		},
+		{
+			"DNS name excluded",
+			NewServerCert(WithDNSNames("db.secret.tenant.example.com")),
+			"x509: DNS name \"db.secret.tenant.example.com\" is excluded by the CA name constraint \"secret.tenant.example.com\"",
+		},
+		{
+			"IP address not permitted",
+			NewServerCert(WithIPs(net.ParseIP("192.168.0.1"))),
+			"x509: IP address \"192.168.0.1\" is not permitted by the CA name constraints",
+		},
+		{
+			"IP address excluded",
+			NewServerCert(WithIPs(net.ParseIP("10.1.2.3"))),
+			"x509: IP address \"10.1.2.3\" is excluded by the CA name constraint \"10.1.0.0/16\"",
+		},
+		{
+			"email address not permitted",
+			NewClientCert(WithEmailAddresses("admin@example.com")),
+			"x509: email address \"admin@example.com\" is not permitted by the CA name constraints",
+		},
+		{
+			"URI not permitted",
+			NewClientCert(WithURIs(u)),
+			"x509: URI \"https://tenant.example.com/api\" is not permitted by the CA name constraints",
+		},
+	}
+
+	for _, v := range tests {
+		t.Run("should return error: "+v.title, func(t *testing.T) {
+			_, _, err := g.Create(v.cert)
+			assert.Error(t, err)
+			assert.Equal(t, v.expected, err.Error())
+		})
+	}
+}
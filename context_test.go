@@ -0,0 +1,165 @@
+package crt_test
+
+import (
+	"context"
+	"crypto"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+type ctxKey struct{}
+
+// blockingKey blocks Gen until the release channel is closed.
+type blockingKey struct {
+	*key.EcdsaKey
+	release chan struct{}
+}
+
+func (k *blockingKey) Gen() (crypto.Signer, error) {
+	<-k.release
+	return k.EcdsaKey.Gen()
+}
+
+// contextKey records the context of GenContext.
+type contextKey struct {
+	*key.EcdsaKey
+	ctx context.Context
+}
+
+func (k *contextKey) GenContext(ctx context.Context) (crypto.Signer, error) {
+	k.ctx = ctx
+	return k.EcdsaKey.Gen()
+}
+
+// remoteSigner records the context of SignContext.
+type remoteSigner struct {
+	crypto.Signer
+	ctx context.Context
+}
+
+func (s *remoteSigner) SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.ctx = ctx
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+// contextWriter records the certificates written by WriteContext.
+type contextWriter struct {
+	ctx    context.Context
+	cert   []byte
+	closed bool
+}
+
+func (w *contextWriter) Write(_, _ []byte) error { return nil }
+
+func (w *contextWriter) WriteContext(ctx context.Context, cert, _ []byte) error {
+	w.ctx, w.cert = ctx, cert
+	return nil
+}
+
+func (w *contextWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestCreateContext(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "foo")
+
+	t.Run("should return error: context canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, _, err := g.CreateContext(canceled, NewServerCert())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should return error: deadline exceeded", func(t *testing.T) {
+		keyG := &blockingKey{EcdsaKey: key.NewEcdsaKey(nil), release: make(chan struct{})}
+		defer close(keyG.release)
+
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, _, err := g.CreateWithOptionsContext(timeout, NewServerCert(), generator.CreateOptions{G: keyG})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("context generator", func(t *testing.T) {
+		keyG := &contextKey{EcdsaKey: key.NewEcdsaKey(nil)}
+		_, _, err := g.CreateWithOptionsContext(ctx, NewServerCert(), generator.CreateOptions{G: keyG})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", keyG.ctx.Value(ctxKey{}))
+	})
+
+	t.Run("context signer", func(t *testing.T) {
+		ca, caKey := g.CA()
+		signer := &remoteSigner{Signer: caKey.(crypto.Signer)}
+		remote := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(ca, signer))
+		_, _, err := remote.CreateContext(ctx, NewClientCert())
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", signer.ctx.Value(ctxKey{}))
+
+		_, _, err = remote.Create(NewClientCert())
+		assert.NoError(t, err)
+		assert.Nil(t, signer.ctx.Value(ctxKey{}))
+	})
+
+	t.Run("context writer", func(t *testing.T) {
+		w := &contextWriter{}
+		err := g.CreateAndWriteContext(ctx, w, NewServerCert())
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", w.ctx.Value(ctxKey{}))
+		assert.NotEmpty(t, w.cert)
+		assert.True(t, w.closed)
+	})
+	t.Run("context signer of CSR and CRL", func(t *testing.T) {
+		ca, caKey := g.CA()
+		signer := &remoteSigner{Signer: caKey.(crypto.Signer)}
+		remote := generator.New(generator.WithCA(ca, signer))
+
+		reqKey, err := key.NewEcdsaKey(nil).Gen()
+		assert.NoError(t, err)
+		csr, err := generator.CreateCSR(NewClientCert(), reqKey)
+		assert.NoError(t, err)
+		_, err = remote.SignCSRContext(ctx, csr, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", signer.ctx.Value(ctxKey{}))
+
+		signer.ctx = nil
+		_, err = remote.CreateCRLContext(ctx, generator.CRLOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", signer.ctx.Value(ctxKey{}))
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = remote.SignCSRContext(canceled, csr, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = remote.CreateCRLContext(canceled, generator.CRLOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("file writer", func(t *testing.T) {
+		dir := t.TempDir()
+		certPath, keyPath := filepath.Join(dir, "ctx.crt"), filepath.Join(dir, "ctx.key")
+		w, err := generator.NewFileWriterFromPaths(certPath, keyPath)
+		assert.NoError(t, err)
+		assert.NoError(t, g.CreateAndWriteContext(ctx, w, NewServerCert()))
+		written, err := os.ReadFile(certPath)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, written)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		w, err = generator.NewFileWriterFromPaths(certPath, keyPath)
+		assert.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		assert.ErrorIs(t, w.WriteContext(canceled, []byte("cert"), []byte("key")), context.Canceled)
+	})
+}
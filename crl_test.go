@@ -0,0 +1,137 @@
+package crt_test
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+// failingSigner fails to sign until it is reset.
+type failingSigner struct {
+	crypto.Signer
+	fail bool
+}
+
+func (s *failingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.fail {
+		return nil, errors.New("signer is unavailable")
+	}
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func parseCRLBytes(t *testing.T, data []byte) *x509.RevocationList {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	assert.NoError(t, err)
+	return crl
+}
+
+func TestCreateCRL(t *testing.T) {
+	t.Run("should return error: CA certificate or private key is not provided", func(t *testing.T) {
+		_, err := generator.New().CreateCRL(generator.CRLOptions{})
+		assert.Equal(t, "x509: CA certificate or private key is not provided", err.Error())
+	})
+
+	t.Run("should contain the revoked certificates", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		ca, _ := g.CA()
+		certRaw, _, err := g.Create(NewServerCert(WithCRLDistributionPoints("http://crl.example.com/ca.crl")))
+		assert.NoError(t, err)
+		parsed, err := parseCertBytes(certRaw)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"http://crl.example.com/ca.crl"}, parsed.CRLDistributionPoints)
+
+		revokedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		assert.NoError(t, g.Revoke(parsed.SerialNumber, generator.ReasonKeyCompromise, revokedAt))
+		assert.NoError(t, g.Revoke(big.NewInt(1), generator.ReasonSuperseded, time.Time{}))
+		revoked, ok := g.IsRevoked(parsed.SerialNumber)
+		assert.True(t, ok)
+		assert.Equal(t, generator.ReasonKeyCompromise, revoked.ReasonCode)
+
+		thisUpdate := time.Now().UTC().Truncate(time.Second)
+		crlRaw, err := g.CreateCRL(generator.CRLOptions{ThisUpdate: thisUpdate})
+		assert.NoError(t, err)
+		block, _ := pem.Decode(crlRaw)
+		assert.Equal(t, "X509 CRL", block.Type)
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		assert.NoError(t, err)
+		assert.NoError(t, crl.CheckSignatureFrom(ca))
+		assert.Equal(t, int64(1), crl.Number.Int64())
+		assert.Equal(t, thisUpdate, crl.ThisUpdate)
+		assert.Equal(t, thisUpdate.Add(7*24*time.Hour), crl.NextUpdate)
+		assert.Equal(t, 2, len(crl.RevokedCertificateEntries))
+		assert.Equal(t, int64(1), crl.RevokedCertificateEntries[0].SerialNumber.Int64())
+		assert.Equal(t, generator.ReasonSuperseded, crl.RevokedCertificateEntries[0].ReasonCode)
+		assert.Equal(t, parsed.SerialNumber, crl.RevokedCertificateEntries[1].SerialNumber)
+		assert.Equal(t, revokedAt, crl.RevokedCertificateEntries[1].RevocationTime)
+		assert.Equal(t, generator.ReasonKeyCompromise, crl.RevokedCertificateEntries[1].ReasonCode)
+
+		crlRaw, err = g.CreateCRL(generator.CRLOptions{})
+		assert.NoError(t, err)
+		block, _ = pem.Decode(crlRaw)
+		crl, err = x509.ParseRevocationList(block.Bytes)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), crl.Number.Int64())
+	})
+
+	t.Run("should return error: serial number is not positive", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		for _, serial := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1)} {
+			err := g.Revoke(serial, generator.ReasonKeyCompromise, time.Time{})
+			assert.EqualError(t, err, "x509: serial number of the revoked certificate must be positive")
+		}
+		assert.Empty(t, g.RevokedCertificates())
+	})
+
+	t.Run("should return error without the crlSign key usage", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+		_, _, err := g.CreateWithOptions(New(WithCAType()), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+		_, err = g.CreateCRL(generator.CRLOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should only contain the revoked certificates of the current CA", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		assert.NoError(t, g.Revoke(big.NewInt(42), generator.ReasonKeyCompromise, time.Time{}))
+		rootCRL, err := g.CreateCRL(generator.CRLOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(parseCRLBytes(t, rootCRL).RevokedCertificateEntries))
+
+		_, _, err = g.CreateWithOptions(NewIntermediateCACert(), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+		_, ok := g.IsRevoked(big.NewInt(42))
+		assert.False(t, ok)
+		interCRL, err := g.CreateCRL(generator.CRLOptions{})
+		assert.NoError(t, err)
+		crl := parseCRLBytes(t, interCRL)
+		assert.Equal(t, 0, len(crl.RevokedCertificateEntries))
+		assert.Equal(t, int64(1), crl.Number.Int64())
+	})
+
+	t.Run("should not consume the CRL number if the CRL is not signed", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		ca, caKey := g.CA()
+		signer := &failingSigner{Signer: caKey.(crypto.Signer), fail: true}
+		g.SetCA(ca, signer)
+		_, err := g.CreateCRL(generator.CRLOptions{})
+		assert.Error(t, err)
+
+		signer.fail = false
+		crlRaw, err := g.CreateCRL(generator.CRLOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), parseCRLBytes(t, crlRaw).Number.Int64())
+	})
+}
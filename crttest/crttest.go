@@ -0,0 +1,92 @@
+package crttest
+
+import (
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/shipengqi/crt/generator"
+)
+
+// Epoch is the initial time of the Clock of the Generator created by NewGenerator.
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a crt.Clock that only changes when it is set or advanced.
+// A Clock is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a new Clock of the given time.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the current time of the Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set sets the current time of the Clock.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+}
+
+// Add advances the current time of the Clock by the given duration.
+func (c *Clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// SerialNumber is a deterministic crt.SerialNumberGenerator, it generates
+// 128-bit serial numbers derived from the seed.
+type SerialNumber struct {
+	rand io.Reader
+}
+
+// NewSerialNumber returns a deterministic serial number generator.
+func NewSerialNumber(seed []byte) *SerialNumber {
+	return &SerialNumber{rand: newReader(seed, "serial")}
+}
+
+// Next implements crt.SerialNumberGenerator interface.
+func (g *SerialNumber) Next() (*big.Int, error) {
+	b := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(g.rand, b); err != nil {
+			return nil, err
+		}
+		if n := new(big.Int).SetBytes(b); n.Sign() > 0 {
+			return n, nil
+		}
+	}
+}
+
+// NewGenerator returns a deterministic generator.Generator, the keys and serial
+// numbers are derived from the given seed, the current time is Epoch.
+// The default key generator is NewEcdsaKey(seed, nil), the given options are
+// applied after the defaults, e.g. generator.WithKeyGenerator(NewRsaKey(seed, 2048)).
+//
+// The certificates created by the Generators of the same seed are byte-identical,
+// if they are created from the same templates in the same order. The Clock and
+// SerialNumberGenerator of the templates, if set, must be deterministic too, and
+// the CA key must be created by a deterministic key generator, or be an RSA or
+// Ed25519 key. The encrypted private keys are not deterministic.
+func NewGenerator(seed []byte, opts ...generator.Option) *generator.Generator {
+	defaults := []generator.Option{
+		generator.WithKeyGenerator(NewEcdsaKey(seed, nil)),
+		generator.WithSerialNumberGenerator(NewSerialNumber(seed)),
+		generator.WithClock(NewClock(Epoch)),
+	}
+	return generator.New(append(defaults, opts...)...)
+}
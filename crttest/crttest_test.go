@@ -0,0 +1,128 @@
+package crttest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+var _seed = []byte("crttest")
+
+// issue creates a CA, an intermediate CA and a server certificate, and returns
+// all the certificates and private keys.
+func issue(t *testing.T, g *generator.Generator) [][]byte {
+	var outputs [][]byte
+	for _, c := range []*crt.Certificate{
+		crt.NewCACert(),
+		crt.NewIntermediateCACert(),
+		crt.NewServerCert(crt.WithDNSNames("example.com")),
+	} {
+		cert, pkey, err := g.CreateWithOptions(c, generator.CreateOptions{UseAsCA: true, AppendCA: true})
+		assert.NoError(t, err)
+		outputs = append(outputs, cert, pkey)
+	}
+	return outputs
+}
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		title string
+		keyG  func(seed []byte) key.Generator
+	}{
+		{"ecdsa", func(seed []byte) key.Generator { return NewEcdsaKey(seed, nil) }},
+		{"rsa", func(seed []byte) key.Generator { return NewRsaKey(seed, 2048) }},
+		{"ed25519", func(seed []byte) key.Generator { return NewEd25519Key(seed) }},
+	}
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			expected := issue(t, NewGenerator(_seed, generator.WithKeyGenerator(v.keyG(_seed))))
+			actual := issue(t, NewGenerator(_seed, generator.WithKeyGenerator(v.keyG(_seed))))
+			assert.Equal(t, expected, actual)
+
+			other := issue(t, NewGenerator([]byte("other"), generator.WithKeyGenerator(v.keyG([]byte("other")))))
+			for i := range expected {
+				assert.NotEqual(t, expected[i], other[i])
+			}
+
+			certs, err := generator.ParseCertificates(actual[len(actual)-2])
+			assert.NoError(t, err)
+			assert.Equal(t, Epoch, certs[0].NotBefore)
+
+			roots, inters := x509.NewCertPool(), x509.NewCertPool()
+			root, err := generator.ParseCertificate(actual[0])
+			assert.NoError(t, err)
+			roots.AddCert(root)
+			inters.AddCert(certs[1])
+			_, err = certs[0].Verify(x509.VerifyOptions{
+				DNSName:       "example.com",
+				Roots:         roots,
+				Intermediates: inters,
+				CurrentTime:   Epoch.Add(time.Hour),
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRsaKey(t *testing.T) {
+	signer, err := NewRsaKey(_seed, 0).Gen()
+	assert.NoError(t, err)
+	pkey := signer.(*rsa.PrivateKey)
+	assert.Equal(t, 2048, pkey.N.BitLen())
+	assert.NoError(t, pkey.Validate())
+}
+
+func TestEcdsaSigner(t *testing.T) {
+	digest := sha256.Sum256([]byte("foo"))
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			keyG := NewEcdsaKey(_seed, curve)
+			signer, err := keyG.Gen()
+			assert.NoError(t, err)
+			assert.IsType(t, &EcdsaSigner{}, signer)
+
+			sig, err := signer.Sign(nil, digest[:], nil)
+			assert.NoError(t, err)
+			again, err := signer.Sign(nil, digest[:], nil)
+			assert.NoError(t, err)
+			assert.Equal(t, sig, again)
+			assert.True(t, ecdsa.VerifyASN1(signer.Public().(*ecdsa.PublicKey), digest[:], sig))
+
+			pem, err := keyG.Marshal(signer, nil)
+			assert.NoError(t, err)
+			parsed, err := key.ParsePrivateKey(pem, nil)
+			assert.NoError(t, err)
+			assert.True(t, signer.(*EcdsaSigner).Equal(parsed))
+		})
+	}
+}
+
+func TestSerialNumber(t *testing.T) {
+	a, b := NewSerialNumber(_seed), NewSerialNumber(_seed)
+	for i := 0; i < 3; i++ {
+		x, err := a.Next()
+		assert.NoError(t, err)
+		y, err := b.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, x, y)
+		assert.LessOrEqual(t, x.BitLen(), 128)
+	}
+}
+
+func TestClock(t *testing.T) {
+	clock := NewClock(Epoch)
+	clock.Add(time.Hour)
+	assert.Equal(t, Epoch.Add(time.Hour), clock.Now())
+	clock.Set(Epoch)
+	assert.Equal(t, Epoch, clock.Now())
+}
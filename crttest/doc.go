@@ -0,0 +1,8 @@
+// Package crttest provides a deterministic mode of certificate generation for
+// golden-file and snapshot tests: the keys, serial numbers and times are derived
+// from a caller-provided seed and clock, so identical inputs produce byte-identical
+// PEM output.
+//
+// This package is for tests only. The private keys are predictable from the seed,
+// the certificates generated by this package must never be used in production.
+package crttest
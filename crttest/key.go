@@ -0,0 +1,240 @@
+package crttest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"io"
+	"math/big"
+
+	"github.com/shipengqi/crt/key"
+)
+
+var (
+	_one  = big.NewInt(1)
+	_two  = big.NewInt(2)
+	_rsaE = big.NewInt(65537)
+)
+
+// RsaKey is a deterministic RSA key generator, the primes are searched from
+// the random numbers derived from the seed. The key pairs generated by the
+// RsaKey of the same seed and bit size are identical, in the same order.
+type RsaKey struct {
+	*key.RsaKey
+	bits int
+	rand io.Reader
+}
+
+// NewRsaKey return a deterministic RSA key generator.
+// If the bit size less than 2048 bits, set to 2048 bits.
+func NewRsaKey(seed []byte, bits int) *RsaKey {
+	if bits < key.DefaultKeyLength {
+		bits = key.DefaultKeyLength
+	}
+	return &RsaKey{RsaKey: key.NewRsaKey(bits), bits: bits, rand: newReader(seed, "rsa")}
+}
+
+// Gen generates a public and private key pair.
+// And returns a crypto.Singer.
+func (g *RsaKey) Gen() (crypto.Signer, error) {
+	for {
+		p, err := g.prime(g.bits / 2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := g.prime(g.bits - g.bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		pminus1 := new(big.Int).Sub(p, _one)
+		qminus1 := new(big.Int).Sub(q, _one)
+		gcd := new(big.Int).GCD(nil, nil, pminus1, qminus1)
+		lambda := new(big.Int).Mul(pminus1, qminus1)
+		lambda.Div(lambda, gcd)
+		d := new(big.Int).ModInverse(_rsaE, lambda)
+		if d == nil {
+			continue
+		}
+
+		pkey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: int(_rsaE.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err = pkey.Validate(); err != nil {
+			return nil, err
+		}
+		pkey.Precompute()
+		return pkey, nil
+	}
+}
+
+// prime returns the first prime p of the given bit size from a random number,
+// the two most significant bits of p are set, so the product of two primes
+// has the full bit size, and p-1 is coprime to the public exponent.
+func (g *RsaKey) prime(bits int) (*big.Int, error) {
+	b := make([]byte, (bits+7)/8)
+	for {
+		if _, err := io.ReadFull(g.rand, b); err != nil {
+			return nil, err
+		}
+		p := new(big.Int).SetBytes(b)
+		p.Rsh(p, uint(len(b)*8-bits))
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, bits-2, 1)
+		p.SetBit(p, 0, 1)
+
+		for ; p.BitLen() == bits; p.Add(p, _two) {
+			if !p.ProbablyPrime(20) {
+				continue
+			}
+			if new(big.Int).Mod(new(big.Int).Sub(p, _one), _rsaE).Sign() == 0 {
+				continue
+			}
+			return p, nil
+		}
+	}
+}
+
+// EcdsaKey is a deterministic ECDSA key generator, the private scalars are
+// derived from the seed. The key pairs generated by the EcdsaKey of the same
+// seed and curve are identical, in the same order.
+type EcdsaKey struct {
+	*key.EcdsaKey
+	curve elliptic.Curve
+	rand  io.Reader
+}
+
+// NewEcdsaKey return a deterministic ECDSA key generator.
+// If the curve is nil, elliptic.P256() is used.
+func NewEcdsaKey(seed []byte, curve elliptic.Curve) *EcdsaKey {
+	if curve == nil {
+		curve = elliptic.P256()
+	}
+	return &EcdsaKey{EcdsaKey: key.NewEcdsaKey(curve), curve: curve, rand: newReader(seed, "ecdsa")}
+}
+
+// Gen generates a public and private key pair.
+// And returns a *EcdsaSigner, which signs deterministically.
+func (g *EcdsaKey) Gen() (crypto.Signer, error) {
+	d, err := randScalar(g.rand, g.curve.Params().N)
+	if err != nil {
+		return nil, err
+	}
+	x, y := scalarBaseMult(g.curve, d)
+	return &EcdsaSigner{PrivateKey: &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: g.curve, X: x, Y: y},
+		D:         d,
+	}}, nil
+}
+
+// Marshal converts an EC private key to SEC 1 or PKCS#8, ASN.1 DER form.
+// And returns the private key encoded in PEM blocks.
+// The encrypted private keys are not deterministic.
+func (g *EcdsaKey) Marshal(pkey crypto.Signer, opts *key.MarshalOptions) ([]byte, error) {
+	if signer, ok := pkey.(*EcdsaSigner); ok {
+		pkey = signer.PrivateKey
+	}
+	return g.EcdsaKey.Marshal(pkey, opts)
+}
+
+// EcdsaSigner wraps an ECDSA private key, the signatures are deterministic,
+// the nonce of a signature is derived from the private key and the digest,
+// as the crypto/ecdsa package always uses random nonces.
+type EcdsaSigner struct {
+	*ecdsa.PrivateKey
+}
+
+// Sign signs the digest with the private key, the rand is ignored.
+// And returns the ASN.1 encoded signature.
+func (s *EcdsaSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	curve := s.Curve
+	n := curve.Params().N
+	size := (n.BitLen() + 7) / 8
+	e := hashToInt(digest, n)
+
+	nonces := newReader(append(s.D.FillBytes(make([]byte, size)), digest...), "ecdsa-nonce")
+	for {
+		k, err := randScalar(nonces, n)
+		if err != nil {
+			return nil, err
+		}
+		x, _ := scalarBaseMult(curve, k)
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		sig := new(big.Int).Mul(r, s.D)
+		sig.Add(sig, e)
+		sig.Mul(sig, new(big.Int).ModInverse(k, n))
+		sig.Mod(sig, n)
+		if sig.Sign() == 0 {
+			continue
+		}
+		return asn1.Marshal(struct{ R, S *big.Int }{r, sig})
+	}
+}
+
+// Ed25519Key is a deterministic Ed25519 key generator, the private key seeds
+// are derived from the seed. The key pairs generated by the Ed25519Key of the
+// same seed are identical, in the same order.
+type Ed25519Key struct {
+	*key.Ed25519Key
+	rand io.Reader
+}
+
+// NewEd25519Key return a deterministic Ed25519 key generator.
+func NewEd25519Key(seed []byte) *Ed25519Key {
+	return &Ed25519Key{Ed25519Key: key.NewEd25519Key(), rand: newReader(seed, "ed25519")}
+}
+
+// Gen generates a public and private key pair.
+// And returns a crypto.Singer.
+func (g *Ed25519Key) Gen() (crypto.Signer, error) {
+	b := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(g.rand, b); err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(b), nil
+}
+
+// randScalar returns a number in [1, n-1] from the given reader,
+// 64 extra bits are read to make the bias negligible.
+func randScalar(r io.Reader, n *big.Int) (*big.Int, error) {
+	b := make([]byte, (n.BitLen()+64+7)/8)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(b)
+	k.Mod(k, new(big.Int).Sub(n, _one))
+	return k.Add(k, _one), nil
+}
+
+// scalarBaseMult returns k*G of the given curve.
+func scalarBaseMult(curve elliptic.Curve, k *big.Int) (x, y *big.Int) {
+	size := (curve.Params().N.BitLen() + 7) / 8
+	//nolint:staticcheck
+	return curve.ScalarBaseMult(k.FillBytes(make([]byte, size)))
+}
+
+// hashToInt converts a hash value to an integer, the hash is truncated to
+// the bit length of the order of the curve, see SEC 1, Section 4.1.3.
+func hashToInt(hash []byte, n *big.Int) *big.Int {
+	orderBits := n.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+	ret := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - orderBits; excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
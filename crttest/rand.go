@@ -0,0 +1,59 @@
+package crttest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// reader is a deterministic stream of bytes, the blocks are HMAC-SHA256 of the
+// label and a counter, keyed by the seed.
+type reader struct {
+	mu      sync.Mutex
+	seed    []byte
+	label   string
+	counter uint64
+	buf     []byte
+}
+
+// NewReader returns a deterministic io.Reader derived from the given seed.
+// The readers of the same seed produce the same stream of bytes.
+func NewReader(seed []byte) io.Reader {
+	return newReader(seed, "reader")
+}
+
+// newReader returns a deterministic reader, the readers of the same seed and
+// different labels produce independent streams.
+func newReader(seed []byte, label string) *reader {
+	return &reader{seed: append([]byte(nil), seed...), label: label}
+}
+
+// Read implements io.Reader interface, it never returns an error.
+func (r *reader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			r.buf = r.block()
+		}
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return n, nil
+}
+
+func (r *reader) block() []byte {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], r.counter)
+	r.counter++
+
+	mac := hmac.New(sha256.New, r.seed)
+	mac.Write([]byte(r.label))
+	mac.Write(counter[:])
+	return mac.Sum(nil)
+}
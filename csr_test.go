@@ -0,0 +1,73 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestSignCSR(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	ca, _ := g.CA()
+	signer, err := key.NewEcdsaKey(nil).Gen()
+	assert.NoError(t, err)
+
+	t.Run("should use the SANs of the request", func(t *testing.T) {
+		csr, err := generator.CreateCSR(New(
+			WithCN("csr.example.com"),
+			WithDNSNames("csr.example.com"),
+			WithIPs(net.ParseIP("10.0.0.1")),
+		), signer)
+		assert.NoError(t, err)
+
+		certRaw, err := g.SignCSR(csr, NewServerCert(WithValidity(time.Hour)))
+		assert.NoError(t, err)
+		parsed, err := parseCertBytes(certRaw)
+		assert.NoError(t, err)
+		assert.NoError(t, parsed.CheckSignatureFrom(ca))
+		assert.Equal(t, "csr.example.com", parsed.Subject.CommonName)
+		assert.Equal(t, []string{"csr.example.com"}, parsed.DNSNames)
+		assert.Equal(t, "10.0.0.1", parsed.IPAddresses[0].String())
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, parsed.ExtKeyUsage)
+		assert.Equal(t, parsed.NotBefore.Add(time.Hour), parsed.NotAfter)
+		assert.Equal(t, signer.Public(), parsed.PublicKey)
+	})
+
+	t.Run("should use the SANs of the template", func(t *testing.T) {
+		csr, err := generator.CreateCSR(New(WithDNSNames("csr.example.com")), signer)
+		assert.NoError(t, err)
+
+		certRaw, err := g.SignCSR(csr, NewServerCert(WithDNSNames("override.example.com")))
+		assert.NoError(t, err)
+		parsed, err := parseCertBytes(certRaw)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"override.example.com"}, parsed.DNSNames)
+	})
+
+	t.Run("should return error with an invalid signature", func(t *testing.T) {
+		csr, err := generator.CreateCSR(New(WithCN("csr.example.com")), signer)
+		assert.NoError(t, err)
+		req, err := generator.ParseCSR(csr)
+		assert.NoError(t, err)
+		raw := append([]byte{}, req.Raw...)
+		raw[len(raw)-1] ^= 0xff
+
+		_, err = g.SignCSR(raw, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error: CA certificate or private key is not provided", func(t *testing.T) {
+		csr, err := generator.CreateCSR(New(WithCN("csr.example.com")), signer)
+		assert.NoError(t, err)
+
+		_, err = generator.New().SignCSR(csr, nil)
+		assert.Equal(t, "x509: CA certificate or private key is not provided", err.Error())
+	})
+}
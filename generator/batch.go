@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/shipengqi/crt"
+)
+
+// BatchOptions defines options for Generator.CreateBatch.
+// Workers is the maximum number of the certificates that are created in
+// parallel, default is runtime.GOMAXPROCS(0).
+// CreateOptions is used to create every certificate, the UseAsCA is ignored,
+// as the order of the certificates is not guaranteed.
+type BatchOptions struct {
+	Workers       int
+	CreateOptions CreateOptions
+}
+
+// BatchResult is the result of a certificate of Generator.CreateBatch,
+// Err is the error of the certificate, the Cert and Key are nil if it is not nil.
+type BatchResult struct {
+	Cert []byte
+	Key  []byte
+	Err  error
+}
+
+// CreateBatch creates the certificates and private keys based on the given
+// templates in parallel. The results are in the same order as the templates.
+// The context is passed to every certificate, see CreateWithOptionsContext.
+// If the context is done, the certificates that are not created yet fail with
+// the error of the context, and the error of the context is returned.
+func (g *Generator) CreateBatch(ctx context.Context, cs []*crt.Certificate, opts BatchOptions) ([]BatchResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(cs) {
+		workers = len(cs)
+	}
+	copts := opts.CreateOptions
+	copts.UseAsCA = false
+
+	results := make([]BatchResult, len(cs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				cert, pkey, err := g.create(ctx, cs[i], copts)
+				results[i] = BatchResult{Cert: cert, Key: pkey, Err: err}
+			}
+		}()
+	}
+
+	sent := 0
+feed:
+	for ; sent < len(cs); sent++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- sent:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := sent; i < len(cs); i++ {
+		results[i].Err = ctx.Err()
+	}
+	return results, ctx.Err()
+}
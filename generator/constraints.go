@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// checkNameConstraints checks the Subject Alternative Names of the given
+// template against the Name Constraints of the parent CA certificate.
+// The matching rules are the same as the rules of x509.Certificate.Verify.
+// Only the parent is checked, the constraints of its ancestors are not known
+// to the Generator, so a certificate can still fail the verification of a
+// chain whose root or upper intermediate CA has stricter constraints.
+func checkNameConstraints(tmpl, parent *x509.Certificate) error {
+	for _, name := range tmpl.DNSNames {
+		if err := checkName("DNS name", name, parent.PermittedDNSDomains, parent.ExcludedDNSDomains, matchDomainConstraint); err != nil {
+			return err
+		}
+	}
+
+	for _, ip := range tmpl.IPAddresses {
+		if err := checkIP(ip, parent.PermittedIPRanges, parent.ExcludedIPRanges); err != nil {
+			return err
+		}
+	}
+
+	for _, email := range tmpl.EmailAddresses {
+		if err := checkName("email address", email, parent.PermittedEmailAddresses, parent.ExcludedEmailAddresses, matchEmailConstraint); err != nil {
+			return err
+		}
+	}
+
+	for _, uri := range tmpl.URIs {
+		if uri == nil {
+			continue
+		}
+		host := uri.Hostname()
+		if len(host) == 0 {
+			return fmt.Errorf("x509: URI %q has no host, it cannot be checked against the CA name constraints", uri.String())
+		}
+		if net.ParseIP(host) != nil {
+			return fmt.Errorf("x509: URI %q has an IP address host, it cannot be checked against the CA name constraints", uri.String())
+		}
+		err := checkName("URI", uri.String(), parent.PermittedURIDomains, parent.ExcludedURIDomains, func(_, constraint string) bool {
+			return matchDomainConstraint(host, constraint)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkName(kind, name string, permitted, excluded []string, match func(name, constraint string) bool) error {
+	for _, constraint := range excluded {
+		if match(name, constraint) {
+			return fmt.Errorf("x509: %s %q is excluded by the CA name constraint %q", kind, name, constraint)
+		}
+	}
+	if len(permitted) == 0 {
+		return nil
+	}
+	for _, constraint := range permitted {
+		if match(name, constraint) {
+			return nil
+		}
+	}
+	return fmt.Errorf("x509: %s %q is not permitted by the CA name constraints", kind, name)
+}
+
+func checkIP(ip net.IP, permitted, excluded []*net.IPNet) error {
+	for _, constraint := range excluded {
+		if matchIPConstraint(ip, constraint) {
+			return fmt.Errorf("x509: IP address %q is excluded by the CA name constraint %q", ip.String(), constraint.String())
+		}
+	}
+	if len(permitted) == 0 {
+		return nil
+	}
+	for _, constraint := range permitted {
+		if matchIPConstraint(ip, constraint) {
+			return nil
+		}
+	}
+	return fmt.Errorf("x509: IP address %q is not permitted by the CA name constraints", ip.String())
+}
+
+func matchIPConstraint(ip net.IP, constraint *net.IPNet) bool {
+	if constraint == nil {
+		return false
+	}
+	if v4 := ip.To4(); v4 != nil && len(constraint.IP) == net.IPv4len {
+		ip = v4
+	}
+	if len(ip) != len(constraint.IP) {
+		return false
+	}
+	return constraint.Contains(ip)
+}
+
+// matchDomainConstraint reports whether the domain matches the constraint.
+// An empty constraint matches any domain, a constraint with a leading period
+// only matches the subdomains, otherwise the constraint matches the domain
+// itself and its subdomains.
+func matchDomainConstraint(domain, constraint string) bool {
+	if len(constraint) == 0 {
+		return true
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	constraint = strings.ToLower(constraint)
+
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(domain, constraint)
+	}
+	return domain == constraint || strings.HasSuffix(domain, "."+constraint)
+}
+
+// matchEmailConstraint reports whether the email address matches the constraint.
+// A constraint containing "@" matches the exact mailbox, otherwise the constraint
+// is matched against the domain of the email address.
+func matchEmailConstraint(email, constraint string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	if strings.Contains(constraint, "@") {
+		cat := strings.LastIndex(constraint, "@")
+		return email[:at] == constraint[:cat] && strings.EqualFold(email[at+1:], constraint[cat+1:])
+	}
+	return matchDomainConstraint(email[at+1:], constraint)
+}
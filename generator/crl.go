@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	crlBlockType       = "X509 CRL"
+	_defaultCRLUpdates = time.Hour * 24 * 7
+)
+
+// Revocation reason codes, see RFC 5280, section 5.3.1.
+const (
+	ReasonUnspecified          = 0
+	ReasonKeyCompromise        = 1
+	ReasonCACompromise         = 2
+	ReasonAffiliationChanged   = 3
+	ReasonSuperseded           = 4
+	ReasonCessationOfOperation = 5
+	ReasonCertificateHold      = 6
+	ReasonRemoveFromCRL        = 8
+	ReasonPrivilegeWithdrawn   = 9
+	ReasonAACompromise         = 10
+)
+
+// RevokedCertificate describes a certificate revoked by the Generator.
+type RevokedCertificate struct {
+	SerialNumber *big.Int
+	RevokedAt    time.Time
+	ReasonCode   int
+}
+
+// CRLOptions defines options for Generator.CreateCRL.
+// Number is the CRL number, if nil, a number maintained by the Generator for
+// the current CA is used, it starts at 1 and increases with each CRL.
+// ThisUpdate is the issue date of the CRL, if zero, the current time of the
+// Clock of the Generator is used.
+// NextUpdate is the date by which the next CRL will be issued, if zero,
+// ThisUpdate plus 7 days is used.
+type CRLOptions struct {
+	Number     *big.Int
+	ThisUpdate time.Time
+	NextUpdate time.Time
+}
+
+// Revoke records the certificate with the given serial number, issued by the
+// current CA of the Generator, as revoked.
+// If at is zero, the current time of the Clock of the Generator is used. Revoking a certificate again
+// replaces the previous record. The serial number must be positive.
+func (g *Generator) Revoke(serial *big.Int, reason int, at time.Time) error {
+	if serial == nil || serial.Sign() <= 0 {
+		return errors.New("x509: serial number of the revoked certificate must be positive")
+	}
+	if at.IsZero() {
+		at = g.now()
+	}
+	ca, _ := g.CA()
+	id := issuerID(ca)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.revoked[id] == nil {
+		g.revoked[id] = make(map[string]RevokedCertificate)
+	}
+	g.revoked[id][serial.String()] = RevokedCertificate{
+		SerialNumber: new(big.Int).Set(serial),
+		RevokedAt:    at,
+		ReasonCode:   reason,
+	}
+	return nil
+}
+
+// IsRevoked returns the revocation record of the certificate with the given
+// serial number issued by the current CA of the Generator, and whether the
+// certificate is revoked.
+func (g *Generator) IsRevoked(serial *big.Int) (RevokedCertificate, bool) {
+	ca, _ := g.CA()
+	return g.IsRevokedBy(ca, serial)
+}
+
+// IsRevokedBy is the same as IsRevoked, but for the certificate issued by the given CA.
+func (g *Generator) IsRevokedBy(issuer *x509.Certificate, serial *big.Int) (RevokedCertificate, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	r, ok := g.revoked[issuerID(issuer)][serial.String()]
+	return r, ok
+}
+
+// RevokedCertificates returns the certificates revoked by the current CA of
+// the Generator, ordered by serial number.
+func (g *Generator) RevokedCertificates() []RevokedCertificate {
+	ca, _ := g.CA()
+	id := issuerID(ca)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	revoked := make([]RevokedCertificate, 0, len(g.revoked[id]))
+	for _, v := range g.revoked[id] {
+		revoked = append(revoked, v)
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].SerialNumber.Cmp(revoked[j].SerialNumber) < 0
+	})
+	return revoked
+}
+
+// CreateCRL creates a new X.509 v2 Certificate Revocation List of the
+// certificates revoked by the current CA, signed by the CA of the Generator.
+// And returns the CRL encoded in PEM blocks.
+func (g *Generator) CreateCRL(opts CRLOptions) ([]byte, error) {
+	return g.CreateCRLContext(context.Background(), opts)
+}
+
+// CreateCRLContext is the same as CreateCRL, but honors the cancellation and
+// deadline of the given context. The context is passed to the CA private key
+// that implements ContextSigner.
+func (g *Generator) CreateCRLContext(ctx context.Context, opts CRLOptions) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ca, caKey := g.CA()
+	if ca == nil || caKey == nil {
+		return nil, errCANotProvided
+	}
+	signer, ok := caKey.(crypto.Signer)
+	if !ok {
+		return nil, errCAKeyNotSigner
+	}
+	if cs, ok := signer.(ContextSigner); ok {
+		signer = contextSigner{ctx: ctx, ContextSigner: cs}
+	}
+
+	revoked := g.RevokedCertificates()
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, v := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   v.SerialNumber,
+			RevocationTime: v.RevokedAt,
+			ReasonCode:     v.ReasonCode,
+		})
+	}
+
+	thisUpdate := opts.ThisUpdate
+	if thisUpdate.IsZero() {
+		thisUpdate = g.now()
+	}
+	nextUpdate := opts.NextUpdate
+	if nextUpdate.IsZero() {
+		nextUpdate = thisUpdate.Add(_defaultCRLUpdates)
+	}
+
+	// the CRLs are created one by one, so the CRL number is only
+	// consumed if the CRL is signed
+	g.crlMu.Lock()
+	defer g.crlMu.Unlock()
+
+	id := issuerID(ca)
+	number := opts.Number
+	if number == nil {
+		number = g.nextCRLNumber(id)
+	}
+	tmpl := &x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		Number:                    number,
+		ThisUpdate:                thisUpdate,
+		NextUpdate:                nextUpdate,
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca, signer)
+	if err != nil {
+		return nil, err
+	}
+	g.setCRLNumber(id, number)
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  crlBlockType,
+		Bytes: der,
+	}), nil
+}
+
+// nextCRLNumber returns the next CRL number of the given CA.
+func (g *Generator) nextCRLNumber(id string) *big.Int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if last := g.crlNumbers[id]; last != nil {
+		return new(big.Int).Add(last, big.NewInt(1))
+	}
+	return big.NewInt(1)
+}
+
+// setCRLNumber records the last CRL number of the given CA.
+func (g *Generator) setCRLNumber(id string, n *big.Int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.crlNumbers[id] = new(big.Int).Set(n)
+}
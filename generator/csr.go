@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"github.com/shipengqi/crt"
+)
+
+const (
+	certificateBlockType = "CERTIFICATE"
+	csrBlockType         = "CERTIFICATE REQUEST"
+)
+
+// CreateCSR creates a new PKCS #10 certificate signing request based on a template,
+// the request is signed by the given crypto.Signer, so the private key never
+// leaves the caller. And returns the request encoded in PEM blocks.
+func CreateCSR(c *crt.Certificate, signer crypto.Signer) ([]byte, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, c.GenCSR(), signer)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  csrBlockType,
+		Bytes: der,
+	}), nil
+}
+
+// ParseCSR parses a single certificate signing request from the given PEM or ASN.1 DER data.
+func ParseCSR(data []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block != nil {
+		if block.Type != csrBlockType {
+			return nil, errors.New("x509: PEM block is not a certificate request")
+		}
+		data = block.Bytes
+	}
+	return x509.ParseCertificateRequest(data)
+}
+
+// SignCSR verifies the signature of the given certificate signing request,
+// then issues a new X.509 v3 certificate from the CA of the Generator.
+// The subject and the public key are taken from the request, the validity
+// and key usages are taken from the template. The Subject Alternative Names
+// of the template take precedence over the names in the request.
+// If the template is nil, crt.New() is used.
+// And returns the certificate encoded in PEM blocks.
+func (g *Generator) SignCSR(csr []byte, c *crt.Certificate) ([]byte, error) {
+	return g.SignCSRContext(context.Background(), csr, c)
+}
+
+// SignCSRContext is the same as SignCSR, but honors the cancellation and
+// deadline of the given context. The context is passed to the CA private key
+// that implements ContextSigner.
+func (g *Generator) SignCSRContext(ctx context.Context, csr []byte, c *crt.Certificate) ([]byte, error) {
+	req, err := ParseCSR(csr)
+	if err != nil {
+		return nil, err
+	}
+	if err = req.CheckSignature(); err != nil {
+		return nil, err
+	}
+	ca, caKey := g.CA()
+	if ca == nil || caKey == nil {
+		return nil, errCANotProvided
+	}
+	if c == nil {
+		c = crt.New()
+	}
+
+	x509crt, err := g.template(c)
+	if err != nil {
+		return nil, err
+	}
+	x509crt.Subject = req.Subject
+	if !c.HasSANs() {
+		x509crt.DNSNames = req.DNSNames
+		x509crt.IPAddresses = req.IPAddresses
+		x509crt.EmailAddresses = req.EmailAddresses
+		x509crt.URIs = req.URIs
+	}
+
+	v3crt, err := g.sign(ctx, x509crt, ca, req.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCertificate(v3crt), nil
+}
+
+// encodeCertificate returns the given ASN.1 DER certificate encoded in PEM blocks.
+func encodeCertificate(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  certificateBlockType,
+		Bytes: der,
+	})
+}
@@ -1,10 +1,14 @@
 package generator
 
 import (
+	"context"
 	"os"
 )
 
-var _ WriteCloser = &FileWriter{}
+var (
+	_ WriteCloser   = &FileWriter{}
+	_ ContextWriter = &FileWriter{}
+)
 
 // FileWriter implements Writer interface.
 type FileWriter struct {
@@ -49,6 +53,23 @@ func (fw *FileWriter) Write(cert, prik []byte) error {
 	return nil
 }
 
+// WriteContext implements ContextWriter interface, the error of the context
+// is returned if it is done before the certificate or private key is written.
+func (fw *FileWriter) WriteContext(ctx context.Context, cert, prik []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := fw.certf.Write(cert)
+	if err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = fw.prikf.Write(prik)
+	return err
+}
+
 // Close implements Closer interface.
 func (fw *FileWriter) Close() error {
 	err := fw.certf.Close()
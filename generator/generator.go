@@ -1,21 +1,39 @@
 package generator
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
-	"encoding/pem"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
 
 	"github.com/shipengqi/crt"
 	"github.com/shipengqi/crt/key"
 )
 
+// _defaultIssuedLimit is the default maximum number of the recorded certificates.
+const _defaultIssuedLimit = 1024
+
+var (
+	errCANotProvided  = errors.New("x509: CA certificate or private key is not provided")
+	errCAKeyNotSigner = errors.New("x509: CA private key does not implement crypto.Signer")
+)
+
 // CreateOptions defines options for Generator.Create.
 // UseAsCA if true, the given crt.Certificate will be used as the CA
 // certificate for the Generator. If the crt.Certificate is not CA type,
 // UseAsCA will be ignored.
-// AppendCA if true, the CA certificate of the Generator will append to the result.
+// AppendCA if true, the CA certificate of the Generator will append to the result,
+// also for the intermediate CA type. Only the CA certificate that signs the
+// result is appended, not the rest of its chain.
+// If the crt.Certificate is root CA type, AppendCA will be ignored.
 type CreateOptions struct {
 	G        key.Generator
 	KeyOpts  *key.MarshalOptions
@@ -24,16 +42,37 @@ type CreateOptions struct {
 }
 
 // Generator is the main structure of a generator.
+// A Generator is safe for concurrent use, the certificates that are being
+// created when the CA pair is changed by SetCA or CreateOptions.UseAsCA are
+// signed by the previous CA pair.
 type Generator struct {
-	keyG  key.Generator
+	keyG    key.Generator
+	serialG crt.SerialNumberGenerator
+	clock   crt.Clock
+
+	caMu  sync.RWMutex
 	ca    *x509.Certificate
 	caKey crypto.PrivateKey
+
+	mu          sync.Mutex
+	issuedLimit int
+	issued      map[string]*x509.Certificate
+	order       []string
+	pending     map[string]struct{}
+	revoked     map[string]map[string]RevokedCertificate
+	crlNumbers  map[string]*big.Int
+	crlMu       sync.Mutex
 }
 
 // New return a new certificate generator.
 func New(opts ...Option) *Generator {
 	g := &Generator{
-		keyG: key.NewRsaKey(key.RecommendedKeyLength),
+		keyG:        key.NewRsaKey(key.RecommendedKeyLength),
+		issuedLimit: _defaultIssuedLimit,
+		issued:      make(map[string]*x509.Certificate),
+		pending:     make(map[string]struct{}),
+		revoked:     make(map[string]map[string]RevokedCertificate),
+		crlNumbers:  make(map[string]*big.Int),
 	}
 	g.withOptions(opts...)
 
@@ -42,23 +81,44 @@ func New(opts ...Option) *Generator {
 
 // CA returns the CA pair of the Generator.
 func (g *Generator) CA() (ca *x509.Certificate, pkey crypto.PrivateKey) {
+	g.caMu.RLock()
+	defer g.caMu.RUnlock()
+
 	return g.ca, g.caKey
 }
 
 // SetCA is used to set the CA pair of the Generator.
 func (g *Generator) SetCA(ca *x509.Certificate, pkey crypto.PrivateKey) {
+	g.caMu.Lock()
+	defer g.caMu.Unlock()
+
 	g.ca = ca
 	g.caKey = pkey
 }
 
 // Create creates a new X.509 v3 certificate and private key based on a template.
 func (g *Generator) Create(c *crt.Certificate) (cert []byte, pkey []byte, err error) {
-	return g.create(c, CreateOptions{})
+	return g.create(context.Background(), c, CreateOptions{})
+}
+
+// CreateContext is the same as Create, but honors the cancellation and deadline
+// of the given context, see CreateWithOptionsContext.
+func (g *Generator) CreateContext(ctx context.Context, c *crt.Certificate) (cert []byte, pkey []byte, err error) {
+	return g.create(ctx, c, CreateOptions{})
 }
 
 // CreateWithOptions creates a new X.509 v3 certificate and private key based on a template with the given CreateOptions.
 func (g *Generator) CreateWithOptions(c *crt.Certificate, opts CreateOptions) (cert []byte, pkey []byte, err error) {
-	return g.create(c, opts)
+	return g.create(context.Background(), c, opts)
+}
+
+// CreateWithOptionsContext is the same as CreateWithOptions, but honors the
+// cancellation and deadline of the given context. The context is passed to the
+// key generator that implements key.ContextGenerator, and the CA private key
+// that implements ContextSigner. The error of the context is returned if it
+// is done before the certificate is signed.
+func (g *Generator) CreateWithOptionsContext(ctx context.Context, c *crt.Certificate, opts CreateOptions) (cert []byte, pkey []byte, err error) {
+	return g.create(ctx, c, opts)
 }
 
 // CreateAndWrite creates a new X.509 v3 certificate and private key, then execute the Writer.Write.
@@ -71,15 +131,32 @@ func (g *Generator) CreateAndWrite(w WriteCloser, c *crt.Certificate) error {
 	return w.Write(cert, pkey)
 }
 
-func (g *Generator) create(c *crt.Certificate, opts CreateOptions) (cert []byte, pkey []byte, err error) {
+// CreateAndWriteContext is the same as CreateAndWrite, but honors the cancellation
+// and deadline of the given context, see CreateWithOptionsContext. If the Writer
+// implements ContextWriter, WriteContext is executed instead of Write.
+func (g *Generator) CreateAndWriteContext(ctx context.Context, w WriteCloser, c *crt.Certificate) error {
+	cert, pkey, err := g.CreateContext(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+	if cw, ok := w.(ContextWriter); ok {
+		return cw.WriteContext(ctx, cert, pkey)
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return w.Write(cert, pkey)
+}
+
+func (g *Generator) create(ctx context.Context, c *crt.Certificate, opts CreateOptions) (cert []byte, pkey []byte, err error) {
 	keyG := g.keyG
 	if opts.G != nil {
 		keyG = opts.G
 	}
 
-	ca := g.ca
-	caKey := g.caKey
-	signer, err := keyG.Gen()
+	ca, caKey := g.CA()
+	signer, err := key.GenContext(ctx, keyG)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -89,39 +166,245 @@ func (g *Generator) create(c *crt.Certificate, opts CreateOptions) (cert []byte,
 	if err != nil {
 		return nil, nil, err
 	}
-	x509crt := c.Gen()
-	if c.IsCA() { // if the given cert is CA type, skip checking CA certificate and private key
+	x509crt, err := g.template(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	selfSigned := c.IsCA() && !c.IsIntermediateCA()
+	if selfSigned { // if the given cert is root CA type, skip checking CA certificate and private key
 		ca = x509crt
 		caKey = signer
-		// set current CA and CA key for the generator
-		if opts.UseAsCA {
-			g.ca = ca
-			g.caKey = caKey
-		}
 	} else if ca == nil || caKey == nil {
-		return nil, nil, errors.New("x509: CA certificate or private key is not provided")
+		return nil, nil, errCANotProvided
+	} else if c.IsIntermediateCA() {
+		// the path length of the intermediate CA is limited by the path length of the CA
+		limit := maxPathLen(ca)
+		if limit == 0 {
+			return nil, nil, errors.New("x509: CA certificate does not allow issuing intermediate CA certificates")
+		}
+		if n := maxPathLen(x509crt); limit > 0 && n >= limit {
+			return nil, nil, fmt.Errorf("x509: path length %d of the intermediate CA exceeds the limit %d of the CA", n, limit-1)
+		} else if limit > 0 && n < 0 {
+			// an unlimited intermediate CA inherits the remaining path length
+			x509crt.MaxPathLen = limit - 1
+			x509crt.MaxPathLenZero = limit == 1
+		}
 	}
 
-	v3crt, err := x509.CreateCertificate(rand.Reader, x509crt, ca, pub, caKey)
+	v3crt, err := g.sign(ctx, x509crt, ca, pub, caKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	block := &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: v3crt,
+	cert = encodeCertificate(v3crt)
+	if opts.AppendCA && !selfSigned {
+		cert = append(cert, encodeCertificate(ca.Raw)...)
 	}
-	cert = pem.EncodeToMemory(block)
-	if opts.AppendCA && g.ca != nil && g.ca != x509crt {
-		capem := pem.EncodeToMemory(&pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: g.ca.Raw,
-		})
-		cert = append(cert, capem...)
+	// set current CA and CA key for the generator
+	if c.IsCA() && opts.UseAsCA {
+		parsed, err := x509.ParseCertificate(v3crt)
+		if err != nil {
+			return nil, nil, err
+		}
+		g.SetCA(parsed, signer)
 	}
 	return cert, pkey, nil
 }
 
+// ContextSigner is a crypto.Signer that honors the cancellation and deadline
+// of the context, e.g. a CA private key stored in a remote KMS or HSM.
+// If the CA private key of the Generator implements it, SignContext is called
+// with the context of the ...Context methods.
+type ContextSigner interface {
+	crypto.Signer
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// contextSigner binds a context to a ContextSigner, so it can be used as a crypto.Signer.
+type contextSigner struct {
+	ctx context.Context
+	ContextSigner
+}
+
+func (s contextSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(s.ctx, rand, digest, opts)
+}
+
+// Issued returns the certificate with the given serial number issued by the
+// current CA of the Generator, and whether the certificate is recorded by the
+// Generator, see WithIssuedLimit.
+func (g *Generator) Issued(serial *big.Int) (*x509.Certificate, bool) {
+	ca, _ := g.CA()
+	return g.IssuedBy(ca, serial)
+}
+
+// IssuedBy is the same as Issued, but for the certificate issued by the given CA.
+func (g *Generator) IssuedBy(issuer *x509.Certificate, serial *big.Int) (*x509.Certificate, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cert, ok := g.issued[recordKey(issuerID(issuer), serial)]
+	return cert, ok
+}
+
+// Now returns the current time of the Clock of the Generator.
+func (g *Generator) Now() time.Time {
+	return g.now()
+}
+
+// template generates the x509.Certificate of the given crt.Certificate.
+// The serial number is generated by the SerialNumberGenerator of the Generator,
+// and the validity period starts at the current time of the Clock of the
+// Generator, unless the crt.Certificate has its own.
+func (g *Generator) template(c *crt.Certificate) (*x509.Certificate, error) {
+	var (
+		tmpl *x509.Certificate
+		err  error
+	)
+	if g.clock != nil && !c.HasClock() {
+		tmpl, err = c.TemplateAt(g.clock.Now())
+	} else {
+		tmpl, err = c.Template()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.serialG != nil && !c.HasSerialNumber() {
+		if tmpl.SerialNumber, err = g.serialG.Next(); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// sign creates a new X.509 v3 certificate of the given public key,
+// signed by the parent certificate and private key.
+// If it is not self-signed, the template is checked against the Name
+// Constraints of the parent certificate, not of its ancestors, and its serial
+// number must not be recorded or being signed by the parent. The issued
+// certificate is recorded by the Generator, see WithIssuedLimit.
+func (g *Generator) sign(ctx context.Context, tmpl, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.PrivateKey) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cs, ok := priv.(ContextSigner); ok {
+		priv = contextSigner{ctx: ctx, ContextSigner: cs}
+	}
+	if tmpl == parent {
+		der, cert, err := createCertificate(tmpl, parent, pub, priv)
+		if err != nil {
+			return nil, err
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.record(recordKey(issuerID(cert), cert.SerialNumber), cert)
+		return der, nil
+	}
+
+	if err := checkNameConstraints(tmpl, parent); err != nil {
+		return nil, err
+	}
+	k, err := g.reserveSerial(tmpl.SerialNumber, parent)
+	if err != nil {
+		return nil, err
+	}
+	der, cert, err := createCertificate(tmpl, parent, pub, priv)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.pending, k)
+	if err != nil {
+		return nil, err
+	}
+	g.record(k, cert)
+	return der, nil
+}
+
+// reserveSerial reserves the serial number of the given parent certificate
+// until the certificate is signed, and returns the key of its records.
+// The serial number must not be recorded or reserved by the parent.
+func (g *Generator) reserveSerial(serial *big.Int, parent *x509.Certificate) (string, error) {
+	if serial == nil {
+		return "", errors.New("x509: serial number is not provided")
+	}
+	k := recordKey(issuerID(parent), serial)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, issued := g.issued[k]
+	_, pending := g.pending[k]
+	if issued || pending {
+		return "", fmt.Errorf("x509: serial number %s has already been issued by the CA", serial.String())
+	}
+	g.pending[k] = struct{}{}
+	return k, nil
+}
+
+// record records the issued certificate, the oldest records are evicted
+// if the limit is reached. g.mu must be held.
+func (g *Generator) record(k string, cert *x509.Certificate) {
+	if g.issuedLimit < 0 {
+		return
+	}
+	if _, ok := g.issued[k]; !ok {
+		g.order = append(g.order, k)
+	}
+	g.issued[k] = cert
+	if g.issuedLimit > 0 && len(g.order) > g.issuedLimit {
+		delete(g.issued, g.order[0])
+		g.order = g.order[1:]
+	}
+}
+
+// createCertificate creates a new X.509 v3 certificate, and returns its
+// ASN.1 DER form and the parsed certificate.
+func createCertificate(tmpl, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.PrivateKey) ([]byte, *x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return der, cert, nil
+}
+
+// maxPathLen returns the path length constraint of the given CA certificate,
+// it is negative if the path length is unlimited.
+func maxPathLen(ca *x509.Certificate) int {
+	if ca.MaxPathLen < 0 || (ca.MaxPathLen == 0 && !ca.MaxPathLenZero) {
+		return -1
+	}
+	return ca.MaxPathLen
+}
+
+// issuerID returns the identifier of the given CA certificate, it is the
+// hash of the subject and public key of the CA, and empty if the CA is nil.
+func issuerID(ca *x509.Certificate) string {
+	if ca == nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(ca.RawSubject)
+	h.Write(ca.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordKey returns the key of the records of the certificate with the given
+// serial number issued by the given CA.
+func recordKey(id string, serial *big.Int) string {
+	return id + "/" + serial.String()
+}
+
+// now returns the current time of the Clock of the Generator.
+func (g *Generator) now() time.Time {
+	if g.clock != nil {
+		return g.clock.Now()
+	}
+	return time.Now()
+}
+
 // withOptions set options for the Generator.
 func (g *Generator) withOptions(opts ...Option) {
 	for _, opt := range opts {
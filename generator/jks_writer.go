@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"crypto/x509"
+	"os"
+
+	"github.com/shipengqi/crt/jks"
+	"github.com/shipengqi/crt/key"
+)
+
+var _ WriteCloser = &JKSWriter{}
+
+// JKSOptions defines options for JKSWriter.
+// Alias is the alias of the private key entry, if empty,
+// the CommonName of the certificate is used.
+// EntryPassword protects the private key entry, if nil,
+// the password of the key store is used.
+// KeyPassword is used to decrypt the private key, it is required if the
+// private key is encrypted by CreateOptions.KeyOpts.
+// CACerts are appended to the certificate chain, the CA certificates
+// already in the certificate PEM blocks (see CreateOptions.AppendCA)
+// come first.
+// TrustStore if true, only the certificates are written as trusted
+// certificate entries, the private key is ignored.
+type JKSOptions struct {
+	Alias         string
+	EntryPassword []byte
+	KeyPassword   []byte
+	CACerts       []*x509.Certificate
+	TrustStore    bool
+}
+
+// JKSWriter implements Writer interface, it packages the certificate,
+// private key and CA chain into a Java KeyStore file in the JKS format, the
+// JCEKS format is not supported.
+type JKSWriter struct {
+	f        *os.File
+	password []byte
+	opts     JKSOptions
+}
+
+// NewJKSWriter creates a new JKSWriter with the key store *os.File and password.
+func NewJKSWriter(f *os.File, password []byte, opts JKSOptions) *JKSWriter {
+	return &JKSWriter{
+		f:        f,
+		password: password,
+		opts:     opts,
+	}
+}
+
+// NewJKSWriterFromPath creates a new JKSWriter with the given key store path and password.
+func NewJKSWriterFromPath(fpath string, password []byte, opts JKSOptions) (*JKSWriter, error) {
+	f, err := os.Create(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return NewJKSWriter(f, password, opts), nil
+}
+
+// Write implements Writer interface.
+func (w *JKSWriter) Write(cert, prik []byte) error {
+	chain, err := ParseCertificates(cert)
+	if err != nil {
+		return err
+	}
+	chain = append(chain, w.opts.CACerts...)
+
+	var data []byte
+	if w.opts.TrustStore {
+		data, err = jks.EncodeTrustStore(chain, w.password)
+	} else {
+		pkey, perr := key.ParsePrivateKey(prik, w.opts.KeyPassword)
+		if perr != nil {
+			return perr
+		}
+		data, err = jks.Encode(pkey, chain, w.password, w.opts.EntryPassword, w.opts.Alias)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.f.Write(data)
+	return err
+}
+
+// Close implements Closer interface.
+func (w *JKSWriter) Close() error {
+	return w.f.Close()
+}
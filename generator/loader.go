@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+
+	"github.com/shipengqi/crt/key"
+)
+
+var (
+	errNoCertificate = errors.New("x509: no certificate is found")
+	errNotCA         = errors.New("x509: certificate is not a CA certificate")
+	errKeyMismatch   = errors.New("x509: private key does not match the public key of the certificate")
+)
+
+// NewFromCAFiles reads the given CA certificate and private key files,
+// and returns a new certificate generator with the CA pair.
+// See NewFromCA.
+func NewFromCAFiles(certfile, keyfile string, password []byte, opts ...Option) (*Generator, error) {
+	cert, err := os.ReadFile(certfile)
+	if err != nil {
+		return nil, err
+	}
+	pkey, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromCA(cert, pkey, password, opts...)
+}
+
+// NewFromCA parses the given CA certificate and private key in PEM or ASN.1 DER
+// form, and returns a new certificate generator with the CA pair.
+// The password is used to decrypt the private key, it can be nil.
+func NewFromCA(cert, pkey, password []byte, opts ...Option) (*Generator, error) {
+	ca, caKey, err := LoadCA(cert, pkey, password)
+	if err != nil {
+		return nil, err
+	}
+	merged := append([]Option{WithCA(ca, caKey)}, opts...)
+
+	return New(merged...), nil
+}
+
+// LoadCA parses the given CA certificate and private key in PEM or ASN.1 DER form,
+// and validates that the private key matches the public key of the certificate.
+// The password is used to decrypt the private key, it can be nil.
+func LoadCA(cert, pkey, password []byte) (*x509.Certificate, crypto.Signer, error) {
+	ca, err := ParseCertificate(cert)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ca.IsCA {
+		return nil, nil, errNotCA
+	}
+	signer, err := key.ParsePrivateKey(pkey, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, ok := signer.Public().(interface{ Equal(x crypto.PublicKey) bool })
+	if !ok || !pub.Equal(ca.PublicKey) {
+		return nil, nil, errKeyMismatch
+	}
+	return ca, signer, nil
+}
+
+// ParseCertificate parses the first certificate from the given PEM or ASN.1 DER data.
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	certs, err := ParseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+// ParseCertificates parses all the certificates from the given PEM or ASN.1 DER data.
+// The blocks that are not certificates are skipped.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var (
+		certs []*x509.Certificate
+		block *pem.Block
+	)
+
+	rest := data
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != certificateBlockType {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 && len(rest) == len(data) { // no PEM data is found, try ASN.1 DER form
+		var err error
+		certs, err = x509.ParseCertificates(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errNoCertificate
+	}
+	return certs, nil
+}
@@ -4,6 +4,7 @@ import (
 	"crypto"
 	"crypto/x509"
 
+	"github.com/shipengqi/crt"
 	"github.com/shipengqi/crt/key"
 )
 
@@ -34,3 +35,34 @@ func WithCA(ca *x509.Certificate, key crypto.PrivateKey) Option {
 		g.caKey = key
 	})
 }
+
+// WithSerialNumberGenerator is used to set the serial number generator of the Generator,
+// it is used for the templates without serial number, see crt.Certificate.HasSerialNumber.
+func WithSerialNumberGenerator(s crt.SerialNumberGenerator) Option {
+	return optionFunc(func(g *Generator) {
+		g.serialG = s
+	})
+}
+
+// WithClock is used to set the Clock of the Generator, it provides the current time
+// of the certificates without their own Clock, see crt.Certificate.HasClock,
+// and the current time of the revocations and CRLs.
+func WithClock(clock crt.Clock) Option {
+	return optionFunc(func(g *Generator) {
+		g.clock = clock
+	})
+}
+
+// WithIssuedLimit is used to set the maximum number of the issued certificates
+// recorded by the Generator, the oldest records are evicted once the limit is
+// reached, default is 1024. Zero means unlimited, and a negative value turns
+// the recording off. A Generator that issues many certificates, e.g. by
+// CreateBatch, only keeps the latest records unless the limit is raised.
+// The records are used by Issued, IssuedBy and the OCSP responder, and to
+// reject the serial numbers that have already been issued by the CA, so the
+// duplicates of the evicted or unrecorded certificates are not detected.
+func WithIssuedLimit(n int) Option {
+	return optionFunc(func(g *Generator) {
+		g.issuedLimit = n
+	})
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"crypto/x509"
+	"os"
+
+	"github.com/shipengqi/crt/key"
+	"github.com/shipengqi/crt/pkcs12"
+)
+
+var _ WriteCloser = &PKCS12Writer{}
+
+// PKCS12Options defines options for PKCS12Writer.
+// FriendlyName is the alias of the private key entry, if empty,
+// the CommonName of the certificate is used.
+// KeyPassword is used to decrypt the private key, it is required if the
+// private key is encrypted by CreateOptions.KeyOpts.
+// CACerts are appended to the certificate chain, the CA certificates
+// already in the certificate PEM blocks (see CreateOptions.AppendCA)
+// come first.
+// TrustStore if true, only the certificates are written as trusted
+// certificate entries, the private key is ignored.
+type PKCS12Options struct {
+	FriendlyName string
+	KeyPassword  []byte
+	CACerts      []*x509.Certificate
+	TrustStore   bool
+}
+
+// PKCS12Writer implements Writer interface, it packages the certificate,
+// private key and CA chain into a password protected PKCS #12 file.
+type PKCS12Writer struct {
+	f        *os.File
+	password []byte
+	opts     PKCS12Options
+}
+
+// NewPKCS12Writer creates a new PKCS12Writer with the PKCS #12 *os.File and password.
+func NewPKCS12Writer(f *os.File, password []byte, opts PKCS12Options) *PKCS12Writer {
+	return &PKCS12Writer{
+		f:        f,
+		password: password,
+		opts:     opts,
+	}
+}
+
+// NewPKCS12WriterFromPath creates a new PKCS12Writer with the given PKCS #12 path and password.
+func NewPKCS12WriterFromPath(fpath string, password []byte, opts PKCS12Options) (*PKCS12Writer, error) {
+	f, err := os.Create(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return NewPKCS12Writer(f, password, opts), nil
+}
+
+// Write implements Writer interface.
+func (w *PKCS12Writer) Write(cert, prik []byte) error {
+	chain, err := ParseCertificates(cert)
+	if err != nil {
+		return err
+	}
+	chain = append(chain, w.opts.CACerts...)
+
+	var data []byte
+	if w.opts.TrustStore {
+		data, err = pkcs12.EncodeTrustStore(chain, w.password)
+	} else {
+		pkey, perr := key.ParsePrivateKey(prik, w.opts.KeyPassword)
+		if perr != nil {
+			return perr
+		}
+		data, err = pkcs12.Encode(pkey, chain, w.password, w.opts.FriendlyName)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.f.Write(data)
+	return err
+}
+
+// Close implements Closer interface.
+func (w *PKCS12Writer) Close() error {
+	return w.f.Close()
+}
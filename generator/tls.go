@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/shipengqi/crt"
+)
+
+// TLSCertificate creates a new certificate and private key based on a template,
+// and returns them as a tls.Certificate. The CA certificate of the Generator is
+// appended to the chain of the tls.Certificate, unless the template is root CA type.
+func (g *Generator) TLSCertificate(c *crt.Certificate) (tls.Certificate, error) {
+	cert, pkey, err := g.CreateWithOptions(c, CreateOptions{AppendCA: true})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	pair, err := tls.X509KeyPair(cert, pkey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if pair.Leaf == nil {
+		if pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	return pair, nil
+}
+
+// CertPool returns a new x509.CertPool that contains the CA certificate of the Generator.
+func (g *Generator) CertPool() (*x509.CertPool, error) {
+	ca, _ := g.CA()
+	if ca == nil {
+		return nil, errCANotProvided
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, nil
+}
+
+// ServerTLSConfig returns a new tls.Config for a server, the server certificate
+// is created based on the given template. The client certificates are required
+// and verified by the CA of the Generator, the minimum version is TLS 1.2.
+func (g *Generator) ServerTLSConfig(c *crt.Certificate) (*tls.Config, error) {
+	pool, err := g.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := g.TLSCertificate(c)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTLSConfig returns a new tls.Config for a client, the server certificates
+// are verified by the CA of the Generator, the minimum version is TLS 1.2.
+// The client certificate is created based on the given template, if the template
+// is nil, no client certificate is sent.
+func (g *Generator) ClientTLSConfig(c *crt.Certificate) (*tls.Config, error) {
+	pool, err := g.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	if c == nil {
+		return config, nil
+	}
+	cert, err := g.TLSCertificate(c)
+	if err != nil {
+		return nil, err
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return config, nil
+}
@@ -1,5 +1,7 @@
 package generator
 
+import "context"
+
 // Writer is the interface that wraps the basic Write method.
 type Writer interface {
 	// Write writes certificate and private key
@@ -16,3 +18,11 @@ type WriteCloser interface {
 	Writer
 	Closer
 }
+
+// ContextWriter is the interface that wraps the WriteContext method, a Writer
+// can implement it to honor the cancellation and deadline of the context,
+// e.g. a Writer that writes to a remote storage.
+type ContextWriter interface {
+	// WriteContext writes certificate and private key
+	WriteContext(ctx context.Context, cert, prik []byte) error
+}
@@ -2,6 +2,7 @@ package crt
 
 import (
 	"net"
+	"net/url"
 )
 
 func deduplicateips(ips []net.IP) []net.IP {
@@ -38,3 +39,19 @@ func deduplicatestr(s []string) []string {
 	}
 	return ret
 }
+
+func deduplicateuris(uris []*url.URL) []*url.URL {
+	encountered := map[string]struct{}{}
+	ret := make([]*url.URL, 0)
+	for i := range uris {
+		if uris[i] == nil {
+			continue
+		}
+		if _, contained := encountered[uris[i].String()]; contained {
+			continue
+		}
+		encountered[uris[i].String()] = struct{}{}
+		ret = append(ret, uris[i])
+	}
+	return ret
+}
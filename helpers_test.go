@@ -2,6 +2,7 @@ package crt
 
 import (
 	"net"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,3 +55,12 @@ func TestDeDuplicateStr(t *testing.T) {
 		})
 	}
 }
+
+func TestDeDuplicateURIs(t *testing.T) {
+	u1, _ := url.Parse("spiffe://example.org/foo")
+	u2, _ := url.Parse("https://example.org")
+	u3, _ := url.Parse("spiffe://example.org/foo")
+
+	got := deduplicateuris([]*url.URL{u1, nil, u2, u3})
+	assert.Equal(t, []*url.URL{u1, u2}, got)
+}
@@ -0,0 +1,3 @@
+// Package inspect parses certificates, certificate signing requests and
+// certificate revocation lists, and describes them in text or JSON form.
+package inspect
@@ -0,0 +1,384 @@
+package inspect
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The types of Info.
+const (
+	TypeCertificate        = "certificate"
+	TypeCertificateRequest = "certificate request"
+	TypeRevocationList     = "revocation list"
+)
+
+const (
+	certificateBlockType    = "CERTIFICATE"
+	csrBlockType            = "CERTIFICATE REQUEST"
+	legacyCSRBlockType      = "NEW CERTIFICATE REQUEST"
+	revocationListBlockType = "X509 CRL"
+)
+
+var errNoData = errors.New("inspect: no certificate, certificate request or revocation list is found")
+
+// Info is the structured description of a certificate, a certificate
+// request or a revocation list. The fields that do not apply to the
+// Type are empty.
+type Info struct {
+	Type               string       `json:"type"`
+	Version            int          `json:"version"`
+	Subject            string       `json:"subject,omitempty"`
+	Issuer             string       `json:"issuer,omitempty"`
+	SerialNumber       string       `json:"serial_number,omitempty"`
+	NotBefore          *time.Time   `json:"not_before,omitempty"`
+	NotAfter           *time.Time   `json:"not_after,omitempty"`
+	SignatureAlgorithm string       `json:"signature_algorithm"`
+	PublicKey          *PublicKey   `json:"public_key,omitempty"`
+	IsCA               bool         `json:"is_ca"`
+	IsServerCert       bool         `json:"is_server_cert"`
+	IsClientCert       bool         `json:"is_client_cert"`
+	MaxPathLen         *int         `json:"max_path_len,omitempty"`
+	DNSNames           []string     `json:"dns_names,omitempty"`
+	IPAddresses        []string     `json:"ip_addresses,omitempty"`
+	URIs               []string     `json:"uris,omitempty"`
+	EmailAddresses     []string     `json:"email_addresses,omitempty"`
+	KeyUsages          []string     `json:"key_usages,omitempty"`
+	ExtKeyUsages       []string     `json:"ext_key_usages,omitempty"`
+	SubjectKeyID       string       `json:"subject_key_id,omitempty"`
+	AuthorityKeyID     string       `json:"authority_key_id,omitempty"`
+	CRLDistribution    []string     `json:"crl_distribution_points,omitempty"`
+	OCSPServers        []string     `json:"ocsp_servers,omitempty"`
+	IssuingCertURLs    []string     `json:"issuing_certificate_urls,omitempty"`
+	Policies           []string     `json:"policies,omitempty"`
+	Extensions         []Extension  `json:"extensions,omitempty"`
+	Fingerprints       Fingerprints `json:"fingerprints"`
+
+	// the fields of the revocation list
+	CRLNumber   string     `json:"crl_number,omitempty"`
+	ThisUpdate  *time.Time `json:"this_update,omitempty"`
+	NextUpdate  *time.Time `json:"next_update,omitempty"`
+	Revocations []Revoked  `json:"revoked_certificates,omitempty"`
+}
+
+// PublicKey describes a public key. Size is the size of the key in bits,
+// Curve is the curve name of an ECDSA key.
+type PublicKey struct {
+	Algorithm string `json:"algorithm"`
+	Size      int    `json:"size"`
+	Curve     string `json:"curve,omitempty"`
+}
+
+// Extension describes an extension. Name is empty if the extension is unknown.
+type Extension struct {
+	OID      string `json:"oid"`
+	Name     string `json:"name,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// Fingerprints contains the colon-separated hex digests of the raw data.
+type Fingerprints struct {
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+}
+
+// Revoked describes an entry of a revocation list.
+type Revoked struct {
+	SerialNumber string    `json:"serial_number"`
+	RevokedAt    time.Time `json:"revoked_at"`
+	Reason       string    `json:"reason"`
+}
+
+// Parse parses all the certificates, certificate requests and revocation
+// lists from the given PEM data, the unknown PEM blocks are skipped.
+// If no PEM data is found, the data is parsed in ASN.1 DER form.
+func Parse(data []byte) ([]*Info, error) {
+	var (
+		infos []*Info
+		block *pem.Block
+	)
+
+	rest := data
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		var (
+			info *Info
+			err  error
+		)
+		switch block.Type {
+		case certificateBlockType:
+			info, err = parseCertificate(block.Bytes)
+		case csrBlockType, legacyCSRBlockType:
+			info, err = parseCertificateRequest(block.Bytes)
+		case revocationListBlockType:
+			info, err = parseRevocationList(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) == 0 && len(rest) == len(data) { // no PEM data is found, try ASN.1 DER form
+		info, err := parseDER(data)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) == 0 {
+		return nil, errNoData
+	}
+	return infos, nil
+}
+
+// Certificate returns the Info of the given certificate.
+func Certificate(cert *x509.Certificate) *Info {
+	info := &Info{
+		Type:               TypeCertificate,
+		Version:            cert.Version,
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SerialNumber:       formatSerial(cert.SerialNumber),
+		NotBefore:          timePtr(cert.NotBefore),
+		NotAfter:           timePtr(cert.NotAfter),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		PublicKey:          publicKey(cert.PublicKey),
+		IsCA:               cert.IsCA,
+		KeyUsages:          KeyUsageNames(cert.KeyUsage),
+		ExtKeyUsages:       ExtKeyUsageNames(cert.ExtKeyUsage, cert.UnknownExtKeyUsage),
+		SubjectKeyID:       hexColon(cert.SubjectKeyId),
+		AuthorityKeyID:     hexColon(cert.AuthorityKeyId),
+		CRLDistribution:    cert.CRLDistributionPoints,
+		OCSPServers:        cert.OCSPServer,
+		IssuingCertURLs:    cert.IssuingCertificateURL,
+		Extensions:         extensions(cert.Extensions),
+		Fingerprints:       fingerprints(cert.Raw),
+	}
+	for _, v := range cert.ExtKeyUsage {
+		switch v {
+		case x509.ExtKeyUsageServerAuth:
+			info.IsServerCert = true
+		case x509.ExtKeyUsageClientAuth:
+			info.IsClientCert = true
+		case x509.ExtKeyUsageAny:
+			info.IsServerCert = true
+			info.IsClientCert = true
+		}
+	}
+	if cert.IsCA && cert.BasicConstraintsValid && (cert.MaxPathLen > 0 || cert.MaxPathLenZero) {
+		n := cert.MaxPathLen
+		info.MaxPathLen = &n
+	}
+	for _, oid := range cert.PolicyIdentifiers {
+		info.Policies = append(info.Policies, oid.String())
+	}
+	info.setSANs(cert.DNSNames, cert.EmailAddresses, cert.IPAddresses, cert.URIs)
+	return info
+}
+
+// CertificateRequest returns the Info of the given certificate request.
+func CertificateRequest(csr *x509.CertificateRequest) *Info {
+	info := &Info{
+		Type:               TypeCertificateRequest,
+		Version:            csr.Version,
+		Subject:            csr.Subject.String(),
+		SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+		PublicKey:          publicKey(csr.PublicKey),
+		Extensions:         extensions(csr.Extensions),
+		Fingerprints:       fingerprints(csr.Raw),
+	}
+	info.setSANs(csr.DNSNames, csr.EmailAddresses, csr.IPAddresses, csr.URIs)
+	return info
+}
+
+// RevocationList returns the Info of the given revocation list.
+func RevocationList(crl *x509.RevocationList) *Info {
+	info := &Info{
+		Type:               TypeRevocationList,
+		Version:            2,
+		Issuer:             crl.Issuer.String(),
+		SignatureAlgorithm: crl.SignatureAlgorithm.String(),
+		AuthorityKeyID:     hexColon(crl.AuthorityKeyId),
+		ThisUpdate:         timePtr(crl.ThisUpdate),
+		NextUpdate:         timePtr(crl.NextUpdate),
+		Extensions:         extensions(crl.Extensions),
+		Fingerprints:       fingerprints(crl.Raw),
+	}
+	if crl.Number != nil {
+		info.CRLNumber = crl.Number.String()
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		info.Revocations = append(info.Revocations, Revoked{
+			SerialNumber: formatSerial(entry.SerialNumber),
+			RevokedAt:    entry.RevocationTime,
+			Reason:       ReasonName(entry.ReasonCode),
+		})
+	}
+	return info
+}
+
+// KeyUsageNames returns the names of the given key usage, e.g. "digital_signature".
+func KeyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for _, v := range _keyUsages {
+		if usage&v.usage != 0 {
+			names = append(names, v.name)
+		}
+	}
+	return names
+}
+
+// ExtKeyUsageNames returns the names of the given extended key usages, e.g. "server_auth".
+// The unknown extended key usages are returned in dotted OID form.
+func ExtKeyUsageNames(usages []x509.ExtKeyUsage, unknown []asn1.ObjectIdentifier) []string {
+	var names []string
+	for _, v := range usages {
+		name, ok := _extKeyUsages[v]
+		if !ok {
+			name = fmt.Sprintf("unknown(%d)", v)
+		}
+		names = append(names, name)
+	}
+	for _, oid := range unknown {
+		names = append(names, oid.String())
+	}
+	return names
+}
+
+// ReasonName returns the name of the given revocation reason code, e.g. "key_compromise".
+func ReasonName(code int) string {
+	if name, ok := _reasons[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", code)
+}
+
+func (i *Info) setSANs(dnsNames, emails []string, ips []net.IP, uris []*url.URL) {
+	i.DNSNames = dnsNames
+	i.EmailAddresses = emails
+	for _, ip := range ips {
+		i.IPAddresses = append(i.IPAddresses, ip.String())
+	}
+	for _, u := range uris {
+		i.URIs = append(i.URIs, u.String())
+	}
+}
+
+func parseDER(der []byte) (*Info, error) {
+	if info, err := parseCertificate(der); err == nil {
+		return info, nil
+	}
+	if info, err := parseCertificateRequest(der); err == nil {
+		return info, nil
+	}
+	if info, err := parseRevocationList(der); err == nil {
+		return info, nil
+	}
+	return nil, errNoData
+}
+
+func parseCertificate(der []byte) (*Info, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return Certificate(cert), nil
+}
+
+func parseCertificateRequest(der []byte) (*Info, error) {
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	return CertificateRequest(csr), nil
+}
+
+func parseRevocationList(der []byte) (*Info, error) {
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, err
+	}
+	return RevocationList(crl), nil
+}
+
+func publicKey(pub any) *PublicKey {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return &PublicKey{Algorithm: "RSA", Size: k.N.BitLen()}
+	case *ecdsa.PublicKey:
+		return &PublicKey{Algorithm: "ECDSA", Size: k.Curve.Params().BitSize, Curve: k.Curve.Params().Name}
+	case ed25519.PublicKey:
+		return &PublicKey{Algorithm: "Ed25519", Size: 256}
+	}
+	return &PublicKey{Algorithm: "unknown"}
+}
+
+func extensions(exts []pkix.Extension) []Extension {
+	ret := make([]Extension, 0, len(exts))
+	for _, ext := range exts {
+		ret = append(ret, Extension{
+			OID:      ext.Id.String(),
+			Name:     _extensionNames[ext.Id.String()],
+			Critical: ext.Critical,
+		})
+	}
+	return ret
+}
+
+func fingerprints(raw []byte) Fingerprints {
+	s1 := sha1.Sum(raw)
+	s256 := sha256.Sum256(raw)
+	return Fingerprints{
+		SHA1:   hexColon(s1[:]),
+		SHA256: hexColon(s256[:]),
+	}
+}
+
+// formatSerial returns the serial number in colon-separated hex form, the
+// same form as "openssl x509 -text".
+func formatSerial(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	b := n.Bytes()
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+	return hexColon(b)
+}
+
+func hexColon(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,145 @@
+package inspect
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func createTestData(t *testing.T) (cert, csr, crl []byte) {
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+	_, _, err := g.CreateWithOptions(crt.NewCACert(crt.WithMaxPathLen(0)), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+
+	cert, _, err = g.Create(crt.NewServerCert(
+		crt.WithCN("example.com"),
+		crt.WithDNSNames("example.com", "www.example.com"),
+		crt.WithIPs(net.ParseIP("127.0.0.1")),
+		crt.WithOCSPServers("http://ocsp.example.com"),
+	))
+	assert.NoError(t, err)
+
+	signer, err := key.NewEd25519Key().Gen()
+	assert.NoError(t, err)
+	csr, err = generator.CreateCSR(crt.New(crt.WithCN("csr.example.com"), crt.WithDNSNames("csr.example.com")), signer)
+	assert.NoError(t, err)
+
+	parsed, err := generator.ParseCertificate(cert)
+	assert.NoError(t, err)
+	assert.NoError(t, g.Revoke(parsed.SerialNumber, generator.ReasonKeyCompromise, time.Now().Add(-time.Hour)))
+	crl, err = g.CreateCRL(generator.CRLOptions{})
+	assert.NoError(t, err)
+	return cert, csr, crl
+}
+
+func TestParse(t *testing.T) {
+	cert, csr, crl := createTestData(t)
+	data := bytes.Join([][]byte{cert, csr, crl}, nil)
+
+	infos, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(infos))
+
+	t.Run("certificate", func(t *testing.T) {
+		info := infos[0]
+		parsed, _ := generator.ParseCertificate(cert)
+		assert.Equal(t, TypeCertificate, info.Type)
+		assert.Equal(t, 3, info.Version)
+		assert.Equal(t, "CN=example.com", info.Subject)
+		assert.Equal(t, "CN=CRT GENERATOR CA", info.Issuer)
+		assert.Equal(t, formatSerial(parsed.SerialNumber), info.SerialNumber)
+		assert.Equal(t, parsed.NotAfter, *info.NotAfter)
+		assert.Equal(t, &PublicKey{Algorithm: "ECDSA", Size: 256, Curve: "P-256"}, info.PublicKey)
+		assert.False(t, info.IsCA)
+		assert.True(t, info.IsServerCert)
+		assert.False(t, info.IsClientCert)
+		assert.Equal(t, []string{"example.com", "www.example.com"}, info.DNSNames)
+		assert.Equal(t, []string{"127.0.0.1"}, info.IPAddresses)
+		assert.Equal(t, []string{"digital_signature", "key_encipherment"}, info.KeyUsages)
+		assert.Equal(t, []string{"server_auth"}, info.ExtKeyUsages)
+		assert.Equal(t, []string{"http://ocsp.example.com"}, info.OCSPServers)
+		assert.Equal(t, 95, len(info.Fingerprints.SHA256))
+		assert.Contains(t, info.Extensions, Extension{OID: "2.5.29.15", Name: "key_usage", Critical: true})
+	})
+
+	t.Run("certificate request", func(t *testing.T) {
+		info := infos[1]
+		assert.Equal(t, TypeCertificateRequest, info.Type)
+		assert.Equal(t, "CN=csr.example.com", info.Subject)
+		assert.Equal(t, []string{"csr.example.com"}, info.DNSNames)
+		assert.Equal(t, &PublicKey{Algorithm: "Ed25519", Size: 256}, info.PublicKey)
+		assert.Equal(t, "Ed25519", info.SignatureAlgorithm)
+	})
+
+	t.Run("revocation list", func(t *testing.T) {
+		info := infos[2]
+		assert.Equal(t, TypeRevocationList, info.Type)
+		assert.Equal(t, "CN=CRT GENERATOR CA", info.Issuer)
+		assert.Equal(t, "1", info.CRLNumber)
+		assert.NotNil(t, info.NextUpdate)
+		assert.Equal(t, 1, len(info.Revocations))
+		assert.Equal(t, infos[0].SerialNumber, info.Revocations[0].SerialNumber)
+		assert.Equal(t, "key_compromise", info.Revocations[0].Reason)
+	})
+
+	t.Run("DER form", func(t *testing.T) {
+		block, _ := pem.Decode(cert)
+		infos, err := Parse(block.Bytes)
+		assert.NoError(t, err)
+		assert.Equal(t, "CN=example.com", infos[0].Subject)
+
+		block, _ = pem.Decode(crl)
+		infos, err = Parse(block.Bytes)
+		assert.NoError(t, err)
+		assert.Equal(t, TypeRevocationList, infos[0].Type)
+	})
+
+	t.Run("should return error: no data", func(t *testing.T) {
+		_, err := Parse([]byte("foo"))
+		assert.Equal(t, "inspect: no certificate, certificate request or revocation list is found", err.Error())
+		_, err = Parse(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("foo")}))
+		assert.Error(t, err)
+	})
+}
+
+func TestRender(t *testing.T) {
+	cert, _, crl := createTestData(t)
+	infos, err := Parse(append(cert, crl...))
+	assert.NoError(t, err)
+
+	text := infos[0].String()
+	assert.Contains(t, text, "Certificate:\n")
+	assert.Contains(t, text, "Subject:             CN=example.com\n")
+	assert.Contains(t, text, "DNS Names:           example.com, www.example.com\n")
+	assert.Contains(t, text, "Public Key:          ECDSA 256 bits (P-256)\n")
+	assert.Contains(t, text, "Extension:           key_usage (2.5.29.15) critical\n")
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteText(&buf, infos...))
+	assert.Contains(t, buf.String(), "\n\nRevocation list:\n")
+
+	buf.Reset()
+	assert.NoError(t, WriteJSON(&buf, infos...))
+	var decoded []*Info
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, 2, len(decoded))
+	assert.Equal(t, infos[0].Fingerprints, decoded[0].Fingerprints)
+	assert.Equal(t, infos[1].Revocations[0].Reason, decoded[1].Revocations[0].Reason)
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"cert_sign", "crl_sign"}, KeyUsageNames(x509.KeyUsageCertSign|x509.KeyUsageCRLSign))
+	assert.Equal(t, []string{"client_auth", "1.2.3.4"}, ExtKeyUsageNames([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, []asn1.ObjectIdentifier{{1, 2, 3, 4}}))
+	assert.Equal(t, "unknown(7)", ReasonName(7))
+}
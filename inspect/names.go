@@ -0,0 +1,68 @@
+package inspect
+
+import "crypto/x509"
+
+// _keyUsages is ordered by the bits of x509.KeyUsage.
+var _keyUsages = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digital_signature"},
+	{x509.KeyUsageContentCommitment, "content_commitment"},
+	{x509.KeyUsageKeyEncipherment, "key_encipherment"},
+	{x509.KeyUsageDataEncipherment, "data_encipherment"},
+	{x509.KeyUsageKeyAgreement, "key_agreement"},
+	{x509.KeyUsageCertSign, "cert_sign"},
+	{x509.KeyUsageCRLSign, "crl_sign"},
+	{x509.KeyUsageEncipherOnly, "encipher_only"},
+	{x509.KeyUsageDecipherOnly, "decipher_only"},
+}
+
+var _extKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "any",
+	x509.ExtKeyUsageServerAuth:                     "server_auth",
+	x509.ExtKeyUsageClientAuth:                     "client_auth",
+	x509.ExtKeyUsageCodeSigning:                    "code_signing",
+	x509.ExtKeyUsageEmailProtection:                "email_protection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "ipsec_end_system",
+	x509.ExtKeyUsageIPSECTunnel:                    "ipsec_tunnel",
+	x509.ExtKeyUsageIPSECUser:                      "ipsec_user",
+	x509.ExtKeyUsageTimeStamping:                   "time_stamping",
+	x509.ExtKeyUsageOCSPSigning:                    "ocsp_signing",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "microsoft_server_gated_crypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "netscape_server_gated_crypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "microsoft_commercial_code_signing",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "microsoft_kernel_code_signing",
+}
+
+// _reasons maps the revocation reason codes to names, see RFC 5280, section 5.3.1.
+var _reasons = map[int]string{
+	0:  "unspecified",
+	1:  "key_compromise",
+	2:  "ca_compromise",
+	3:  "affiliation_changed",
+	4:  "superseded",
+	5:  "cessation_of_operation",
+	6:  "certificate_hold",
+	8:  "remove_from_crl",
+	9:  "privilege_withdrawn",
+	10: "aa_compromise",
+}
+
+var _extensionNames = map[string]string{
+	"2.5.29.14":               "subject_key_identifier",
+	"2.5.29.15":               "key_usage",
+	"2.5.29.17":               "subject_alt_name",
+	"2.5.29.18":               "issuer_alt_name",
+	"2.5.29.19":               "basic_constraints",
+	"2.5.29.20":               "crl_number",
+	"2.5.29.21":               "crl_reason",
+	"2.5.29.30":               "name_constraints",
+	"2.5.29.31":               "crl_distribution_points",
+	"2.5.29.32":               "certificate_policies",
+	"2.5.29.35":               "authority_key_identifier",
+	"2.5.29.37":               "ext_key_usage",
+	"1.3.6.1.5.5.7.1.1":       "authority_info_access",
+	"1.3.6.1.5.5.7.48.1.5":    "ocsp_no_check",
+	"1.3.6.1.4.1.11129.2.4.2": "signed_certificate_timestamps",
+}
@@ -0,0 +1,116 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// String returns the human-readable text form of the Info.
+func (i *Info) String() string {
+	var buf bytes.Buffer
+	_ = WriteText(&buf, i)
+	return buf.String()
+}
+
+// WriteText writes the human-readable text form of the given infos to w,
+// the infos are separated by blank lines.
+func WriteText(w io.Writer, infos ...*Info) error {
+	for n, info := range infos {
+		if n > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := info.writeText(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the indented JSON form of the given infos to w as an array.
+func WriteJSON(w io.Writer, infos ...*Info) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if infos == nil {
+		infos = []*Info{}
+	}
+	return enc.Encode(infos)
+}
+
+func (i *Info) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	field := func(name, value string) {
+		if len(value) > 0 {
+			fmt.Fprintf(tw, "  %s:\t%s\n", name, value)
+		}
+	}
+	list := func(name string, values []string) {
+		field(name, strings.Join(values, ", "))
+	}
+
+	fmt.Fprintf(tw, "%s:\n", strings.ToUpper(i.Type[:1])+i.Type[1:])
+	field("Version", fmt.Sprintf("%d", i.Version))
+	field("Subject", i.Subject)
+	field("Issuer", i.Issuer)
+	field("Serial Number", i.SerialNumber)
+	field("Not Before", formatTime(i.NotBefore))
+	field("Not After", formatTime(i.NotAfter))
+	field("This Update", formatTime(i.ThisUpdate))
+	field("Next Update", formatTime(i.NextUpdate))
+	field("CRL Number", i.CRLNumber)
+	field("Signature Algorithm", i.SignatureAlgorithm)
+	if i.PublicKey != nil {
+		key := fmt.Sprintf("%s %d bits", i.PublicKey.Algorithm, i.PublicKey.Size)
+		if len(i.PublicKey.Curve) > 0 {
+			key += " (" + i.PublicKey.Curve + ")"
+		}
+		field("Public Key", key)
+	}
+	if i.Type == TypeCertificate {
+		field("CA", fmt.Sprintf("%t", i.IsCA))
+		if i.MaxPathLen != nil {
+			field("Max Path Length", fmt.Sprintf("%d", *i.MaxPathLen))
+		}
+	}
+	list("DNS Names", i.DNSNames)
+	list("IP Addresses", i.IPAddresses)
+	list("URIs", i.URIs)
+	list("Email Addresses", i.EmailAddresses)
+	list("Key Usages", i.KeyUsages)
+	list("Ext Key Usages", i.ExtKeyUsages)
+	field("Subject Key ID", i.SubjectKeyID)
+	field("Authority Key ID", i.AuthorityKeyID)
+	list("CRL Distribution", i.CRLDistribution)
+	list("OCSP Servers", i.OCSPServers)
+	list("CA Issuers", i.IssuingCertURLs)
+	list("Policies", i.Policies)
+	for _, ext := range i.Extensions {
+		name := ext.OID
+		if len(ext.Name) > 0 {
+			name = ext.Name + " (" + ext.OID + ")"
+		}
+		if ext.Critical {
+			name += " critical"
+		}
+		field("Extension", name)
+	}
+	for _, r := range i.Revocations {
+		field("Revoked", fmt.Sprintf("%s at %s, %s", r.SerialNumber, r.RevokedAt.UTC().Format(time.RFC3339), r.Reason))
+	}
+	field("SHA-1 Fingerprint", i.Fingerprints.SHA1)
+	field("SHA-256 Fingerprint", i.Fingerprints.SHA256)
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,100 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+)
+
+func TestIntermediateCA(t *testing.T) {
+	t.Run("should return error: CA certificate or private key is not provided", func(t *testing.T) {
+		g := generator.New()
+		_, _, err := g.Create(NewIntermediateCACert())
+		assert.Equal(t, "x509: CA certificate or private key is not provided", err.Error())
+	})
+
+	t.Run("root -> intermediate -> leaf", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		root, _ := g.CA()
+
+		interRaw, _, err := g.CreateWithOptions(NewIntermediateCACert(WithMaxPathLen(0)), generator.CreateOptions{
+			UseAsCA:  true,
+			AppendCA: true,
+		})
+		assert.NoError(t, err)
+		interCerts, err := parseMultiCertBytes(interRaw)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(interCerts))
+		inter := interCerts[0]
+		assert.True(t, inter.IsCA)
+		assert.True(t, inter.MaxPathLenZero)
+		assert.Equal(t, "CRT GENERATOR INTERMEDIATE CA", inter.Subject.CommonName)
+		assert.Equal(t, "CRT GENERATOR CA", inter.Issuer.CommonName)
+		assert.Equal(t, root.Raw, interCerts[1].Raw)
+
+		current, _ := g.CA()
+		assert.Equal(t, inter.Raw, current.Raw)
+
+		leafRaw, _, err := g.CreateWithOptions(NewServerCert(WithDNSNames("leaf.example.com")), generator.CreateOptions{
+			AppendCA: true,
+		})
+		assert.NoError(t, err)
+		leafCerts, err := parseMultiCertBytes(leafRaw)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(leafCerts))
+		assert.Equal(t, "CRT GENERATOR INTERMEDIATE CA", leafCerts[0].Issuer.CommonName)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		intermediates := x509.NewCertPool()
+		intermediates.AddCert(leafCerts[1])
+		chains, err := leafCerts[0].Verify(x509.VerifyOptions{
+			DNSName:       "leaf.example.com",
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(chains[0]))
+	})
+
+	t.Run("should return error when the path length is exceeded", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		_, _, err := g.CreateWithOptions(NewIntermediateCACert(WithMaxPathLen(0)), generator.CreateOptions{
+			UseAsCA: true,
+		})
+		assert.NoError(t, err)
+
+		_, _, err = g.Create(NewIntermediateCACert())
+		assert.Equal(t, "x509: CA certificate does not allow issuing intermediate CA certificates", err.Error())
+	})
+
+	t.Run("should limit the path length by the CA", func(t *testing.T) {
+		g := generator.New()
+		_, _, err := g.CreateWithOptions(NewCACert(WithMaxPathLen(2)), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+
+		_, _, err = g.CreateWithOptions(NewIntermediateCACert(WithMaxPathLen(2)), generator.CreateOptions{UseAsCA: true})
+		assert.EqualError(t, err, "x509: path length 2 of the intermediate CA exceeds the limit 1 of the CA")
+
+		raw, _, err := g.CreateWithOptions(NewIntermediateCACert(WithMaxPathLen(1)), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+		inter, err := parseCertBytes(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, inter.MaxPathLen)
+
+		// an unlimited intermediate CA inherits the remaining path length
+		raw, _, err = g.CreateWithOptions(NewIntermediateCACert(), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+		inter, err = parseCertBytes(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, inter.MaxPathLen)
+		assert.True(t, inter.MaxPathLenZero)
+
+		_, _, err = g.Create(NewIntermediateCACert())
+		assert.Equal(t, "x509: CA certificate does not allow issuing intermediate CA certificates", err.Error())
+	})
+}
@@ -0,0 +1,6 @@
+// Package jks defines an encoder of the Java KeyStore (JKS) files.
+//
+// Only the JKS format is written, the JCEKS format, with the keys protected by
+// PBEWithMD5AndTripleDES, is not supported. The JVM still loads the JKS files
+// with the "JCEKS" keystore type, as the JCEKS keystore reads both formats.
+package jks
@@ -0,0 +1,232 @@
+package jks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	_magic        = 0xfeedfeed
+	_version      = 2
+	_saltSize     = 20
+	_whitener     = "Mighty Aphrodite"
+	_certType     = "X.509"
+	_maxAliasSize = 0xffff
+
+	tagPrivateKey  = 1
+	tagTrustedCert = 2
+)
+
+// oidKeyProtector is the OID of the Sun proprietary key protection algorithm.
+var oidKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// TrustStoreEntry is a trusted certificate entry of a JKS trust store.
+// If the Alias is empty, the CommonName of the certificate is used.
+type TrustStoreEntry struct {
+	Cert  *x509.Certificate
+	Alias string
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// Encode encodes the private key and the certificate chain into a JKS key store.
+// The first certificate of the chain must be the certificate of the private key,
+// the rest are the CA certificates. If the alias is empty, the CommonName of the
+// certificate is used. The private key entry is protected by the keyPassword,
+// if it is nil, the storePassword is used.
+func Encode(pkey crypto.PrivateKey, chain []*x509.Certificate, storePassword, keyPassword []byte, alias string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("jks: certificate is not provided")
+	}
+	if alias == "" {
+		alias = chain[0].Subject.CommonName
+	}
+	if keyPassword == nil {
+		keyPassword = storePassword
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(pkey)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := protectKey(der, keyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newEncoder(1)
+	e.writeUint32(tagPrivateKey)
+	if err = e.writeString(normalizeAlias(alias)); err != nil {
+		return nil, err
+	}
+	e.writeTimestamp()
+	e.writeBytes(protected)
+	e.writeUint32(uint32(len(chain)))
+	for _, v := range chain {
+		if err = e.writeCertificate(v); err != nil {
+			return nil, err
+		}
+	}
+	return e.finish(storePassword), nil
+}
+
+// EncodeTrustStore encodes the certificates into a JKS trust store.
+func EncodeTrustStore(certs []*x509.Certificate, password []byte) ([]byte, error) {
+	entries := make([]TrustStoreEntry, 0, len(certs))
+	for _, v := range certs {
+		entries = append(entries, TrustStoreEntry{Cert: v})
+	}
+	return EncodeTrustStoreEntries(entries, password)
+}
+
+// EncodeTrustStoreEntries encodes the trusted certificate entries into a JKS trust store.
+// The aliases must be unique, they are case-insensitive.
+func EncodeTrustStoreEntries(entries []TrustStoreEntry, password []byte) ([]byte, error) {
+	e := newEncoder(len(entries))
+	aliases := make(map[string]struct{}, len(entries))
+	for _, v := range entries {
+		alias := v.Alias
+		if alias == "" {
+			alias = v.Cert.Subject.CommonName
+		}
+		alias = normalizeAlias(alias)
+		if _, ok := aliases[alias]; ok {
+			return nil, errors.New("jks: duplicate alias " + alias)
+		}
+		aliases[alias] = struct{}{}
+
+		e.writeUint32(tagTrustedCert)
+		if err := e.writeString(alias); err != nil {
+			return nil, err
+		}
+		e.writeTimestamp()
+		if err := e.writeCertificate(v.Cert); err != nil {
+			return nil, err
+		}
+	}
+	return e.finish(password), nil
+}
+
+// protectKey encrypts the PKCS #8 private key with the Sun proprietary key protection algorithm.
+// And returns the EncryptedPrivateKeyInfo in ASN.1 DER form.
+func protectKey(plain, password []byte) ([]byte, error) {
+	salt := make([]byte, _saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	passwd := passwordBytes(password)
+
+	// the key stream is SHA1(password || digest), starting with the salt as digest
+	xorKey := make([]byte, 0, len(plain)+sha1.Size)
+	digest := salt
+	for len(xorKey) < len(plain) {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		xorKey = append(xorKey, digest...)
+	}
+
+	encrypted := make([]byte, 0, _saltSize+len(plain)+sha1.Size)
+	encrypted = append(encrypted, salt...)
+	for i := range plain {
+		encrypted = append(encrypted, plain[i]^xorKey[i])
+	}
+	h := sha1.New()
+	h.Write(passwd)
+	h.Write(plain)
+	encrypted = h.Sum(encrypted)
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidKeyProtector,
+			Parameters: asn1.NullRawValue,
+		},
+		EncryptedData: encrypted,
+	})
+}
+
+// passwordBytes returns the password encoded as UTF-16 big-endian bytes, as Java does.
+func passwordBytes(password []byte) []byte {
+	runes := utf16.Encode([]rune(string(password)))
+	out := make([]byte, 0, len(runes)*2)
+	for _, r := range runes {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return out
+}
+
+// normalizeAlias returns the alias in lower case, as the JVM does.
+func normalizeAlias(alias string) string {
+	return strings.ToLower(alias)
+}
+
+type encoder struct {
+	buf bytes.Buffer
+	now time.Time
+}
+
+func newEncoder(count int) *encoder {
+	e := &encoder{now: time.Now()}
+	e.writeUint32(_magic)
+	e.writeUint32(_version)
+	e.writeUint32(uint32(count))
+	return e
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	_ = binary.Write(&e.buf, binary.BigEndian, v)
+}
+
+func (e *encoder) writeTimestamp() {
+	_ = binary.Write(&e.buf, binary.BigEndian, uint64(e.now.UnixMilli()))
+}
+
+// writeString writes the string in the Java modified UTF-8 form, the string
+// must not contain the null character and the supplementary characters, so
+// that the form is the same as UTF-8.
+func (e *encoder) writeString(s string) error {
+	if len(s) > _maxAliasSize || strings.IndexFunc(s, func(r rune) bool { return r == 0 || r > 0xffff }) >= 0 {
+		return errors.New("jks: invalid string " + s)
+	}
+	_ = binary.Write(&e.buf, binary.BigEndian, uint16(len(s)))
+	e.buf.WriteString(s)
+	return nil
+}
+
+func (e *encoder) writeBytes(b []byte) {
+	e.writeUint32(uint32(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *encoder) writeCertificate(cert *x509.Certificate) error {
+	if err := e.writeString(_certType); err != nil {
+		return err
+	}
+	e.writeBytes(cert.Raw)
+	return nil
+}
+
+// finish appends the integrity digest of the key store,
+// it is SHA1(password || "Mighty Aphrodite" || key store).
+func (e *encoder) finish(password []byte) []byte {
+	h := sha1.New()
+	h.Write(passwordBytes(password))
+	h.Write([]byte(_whitener))
+	h.Write(e.buf.Bytes())
+	return h.Sum(e.buf.Bytes())
+}
@@ -0,0 +1,171 @@
+package jks
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type entry struct {
+	tag   uint32
+	alias string
+	key   []byte
+	certs [][]byte
+}
+
+func TestEncode(t *testing.T) {
+	pkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leaf := newCert(t, "Leaf", pkey)
+	ca := newCert(t, "CA", pkey)
+
+	t.Run("should contain the private key entry", func(t *testing.T) {
+		data, err := Encode(pkey, []*x509.Certificate{leaf, ca}, []byte("storepass"), []byte("keypass"), "")
+		assert.NoError(t, err)
+
+		entries := decode(t, data, []byte("storepass"))
+		assert.Equal(t, 1, len(entries))
+		assert.Equal(t, uint32(tagPrivateKey), entries[0].tag)
+		assert.Equal(t, "leaf", entries[0].alias)
+		assert.Equal(t, [][]byte{leaf.Raw, ca.Raw}, entries[0].certs)
+
+		plain := unprotectKey(t, entries[0].key, []byte("keypass"))
+		parsed, err := x509.ParsePKCS8PrivateKey(plain)
+		assert.NoError(t, err)
+		assert.True(t, pkey.Equal(parsed))
+	})
+
+	t.Run("should contain the trusted certificate entries", func(t *testing.T) {
+		data, err := EncodeTrustStoreEntries([]TrustStoreEntry{{Cert: ca, Alias: "Root"}, {Cert: leaf}}, []byte("storepass"))
+		assert.NoError(t, err)
+
+		entries := decode(t, data, []byte("storepass"))
+		assert.Equal(t, 2, len(entries))
+		assert.Equal(t, uint32(tagTrustedCert), entries[0].tag)
+		assert.Equal(t, "root", entries[0].alias)
+		assert.Equal(t, [][]byte{ca.Raw}, entries[0].certs)
+		assert.Equal(t, "leaf", entries[1].alias)
+	})
+
+	t.Run("should return error with duplicate aliases", func(t *testing.T) {
+		_, err := EncodeTrustStore([]*x509.Certificate{ca, ca}, []byte("storepass"))
+		assert.Equal(t, "jks: duplicate alias ca", err.Error())
+	})
+}
+
+// decode verifies the integrity digest of the key store, and returns all the entries.
+func decode(t *testing.T, data, password []byte) []entry {
+	t.Helper()
+
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	h := sha1.New()
+	h.Write(passwordBytes(password))
+	h.Write([]byte(_whitener))
+	h.Write(body)
+	assert.Equal(t, h.Sum(nil), digest)
+
+	r := bytes.NewReader(body)
+	var magic, version, count uint32
+	assert.NoError(t, binary.Read(r, binary.BigEndian, &magic))
+	assert.NoError(t, binary.Read(r, binary.BigEndian, &version))
+	assert.NoError(t, binary.Read(r, binary.BigEndian, &count))
+	assert.Equal(t, uint32(_magic), magic)
+	assert.Equal(t, uint32(_version), version)
+
+	readString := func() string {
+		var n uint16
+		assert.NoError(t, binary.Read(r, binary.BigEndian, &n))
+		b := make([]byte, n)
+		_, _ = r.Read(b)
+		return string(b)
+	}
+	readBytes := func() []byte {
+		var n uint32
+		assert.NoError(t, binary.Read(r, binary.BigEndian, &n))
+		b := make([]byte, n)
+		_, _ = r.Read(b)
+		return b
+	}
+	readCert := func() []byte {
+		assert.Equal(t, _certType, readString())
+		return readBytes()
+	}
+
+	entries := make([]entry, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var e entry
+		var timestamp uint64
+		assert.NoError(t, binary.Read(r, binary.BigEndian, &e.tag))
+		e.alias = readString()
+		assert.NoError(t, binary.Read(r, binary.BigEndian, &timestamp))
+		if e.tag == tagPrivateKey {
+			e.key = readBytes()
+			var n uint32
+			assert.NoError(t, binary.Read(r, binary.BigEndian, &n))
+			for j := uint32(0); j < n; j++ {
+				e.certs = append(e.certs, readCert())
+			}
+		} else {
+			e.certs = append(e.certs, readCert())
+		}
+		entries = append(entries, e)
+	}
+	assert.Equal(t, 0, r.Len())
+	return entries
+}
+
+func unprotectKey(t *testing.T, der, password []byte) []byte {
+	t.Helper()
+
+	var info encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(der, &info)
+	assert.NoError(t, err)
+	assert.True(t, info.Algorithm.Algorithm.Equal(oidKeyProtector))
+
+	data := info.EncryptedData
+	salt, encrypted, checksum := data[:_saltSize], data[_saltSize:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	passwd := passwordBytes(password)
+	plain := make([]byte, len(encrypted))
+	digest := salt
+	for i := 0; i < len(encrypted); i += sha1.Size {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(encrypted); j++ {
+			plain[i+j] = encrypted[i+j] ^ digest[j]
+		}
+	}
+	h := sha1.New()
+	h.Write(passwd)
+	h.Write(plain)
+	assert.Equal(t, h.Sum(nil), checksum)
+	return plain
+}
+
+func newCert(t *testing.T, cn string, pkey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pkey.Public(), pkey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
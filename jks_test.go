@@ -0,0 +1,49 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+)
+
+func TestJKSWriter(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	ca, _ := g.CA()
+	jksPath := "testdata/server.jks"
+
+	t.Run("should write the JKS key store", func(t *testing.T) {
+		filelist = append(filelist, jksPath)
+		w, err := generator.NewJKSWriterFromPath(jksPath, []byte("123456"), generator.JKSOptions{
+			Alias:   "server",
+			CACerts: []*x509.Certificate{ca},
+		})
+		assert.NoError(t, err)
+		err = g.CreateAndWrite(w, NewServerCert())
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(jksPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xfe, 0xed, 0xfe, 0xed}, data[:4])
+		reset()
+	})
+
+	t.Run("should write the JKS trust store", func(t *testing.T) {
+		filelist = append(filelist, jksPath)
+		w, err := generator.NewJKSWriterFromPath(jksPath, []byte("123456"), generator.JKSOptions{
+			TrustStore: true,
+		})
+		assert.NoError(t, err)
+		err = g.CreateAndWrite(w, NewCACert())
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(jksPath)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xfe, 0xed, 0xfe, 0xed}, data[:4])
+		reset()
+	})
+}
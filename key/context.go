@@ -0,0 +1,52 @@
+package key
+
+import (
+	"context"
+	"crypto"
+)
+
+// ContextGenerator is the interface that wraps the GenContext method, a Generator
+// can implement it to honor the cancellation and deadline of the context,
+// e.g. a Generator that creates the keys in a remote KMS.
+type ContextGenerator interface {
+	// GenContext generates a public and private key pair.
+	// And returns a crypto.Singer.
+	GenContext(ctx context.Context) (crypto.Signer, error)
+}
+
+// GenContext generates a public and private key pair with the given Generator.
+// If the Generator implements ContextGenerator, its GenContext is called.
+// If the context can never be done, e.g. context.Background(), Gen is called
+// directly. Otherwise, Gen is called in a new goroutine, and the error of the
+// context is returned if the context is done first.
+//
+// The built-in Generators cannot be interrupted, so the cancellation only
+// stops the wait: the abandoned Gen keeps running in the background until
+// the key pair is generated, then the key pair is discarded.
+func GenContext(ctx context.Context, g Generator) (crypto.Signer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cg, ok := g.(ContextGenerator); ok {
+		return cg.GenContext(ctx)
+	}
+	if ctx.Done() == nil {
+		return g.Gen()
+	}
+
+	type result struct {
+		signer crypto.Signer
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		signer, err := g.Gen()
+		done <- result{signer: signer, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.signer, r.err
+	}
+}
@@ -36,6 +36,9 @@ func (g *EcdsaKey) Marshal(pkey crypto.Signer, opts *MarshalOptions) ([]byte, er
 	if !opts.IsPKCS8 {
 		return g.MarshalECPrivateKey(pkey.(*ecdsa.PrivateKey), opts.Password)
 	}
+	if len(opts.Password) > 0 {
+		return MarshalEncryptedPKCS8PrivateKey(pkey, opts.Password, opts.KDF, opts.Cipher)
+	}
 	return g.MarshalPKCS8PrivateKey(pkey)
 }
 
@@ -0,0 +1,46 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+)
+
+type Ed25519Key struct{}
+
+// NewEd25519Key return an Ed25519 key generator.
+func NewEd25519Key() *Ed25519Key {
+	return &Ed25519Key{}
+}
+
+// Gen generates a public and private key pair.
+// And returns a crypto.Singer.
+func (g *Ed25519Key) Gen() (crypto.Signer, error) {
+	_, pkey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return pkey, nil
+}
+
+// Marshal converts an Ed25519 private key to PKCS #8, ASN.1 DER form.
+// And returns the private key encoded in PEM blocks.
+// Ed25519 private key only has the PKCS #8 form, so the IsPKCS8 is ignored,
+// and the private key is always encrypted with PBES2 if the Password is set.
+func (g *Ed25519Key) Marshal(pkey crypto.Signer, opts *MarshalOptions) ([]byte, error) {
+	if opts != nil && len(opts.Password) > 0 {
+		return MarshalEncryptedPKCS8PrivateKey(pkey, opts.Password, opts.KDF, opts.Cipher)
+	}
+	return g.MarshalPKCS8PrivateKey(pkey)
+}
+
+// MarshalPKCS8PrivateKey converts a private key to PKCS #8, ASN.1 DER form.
+// And returns the private key encoded in PEM blocks.
+func (g *Ed25519Key) MarshalPKCS8PrivateKey(pkey any) ([]byte, error) {
+	b, err := x509.MarshalPKCS8PrivateKey(pkey)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeWithBlockType(b, PKCCS8BlockType), nil
+}
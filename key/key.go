@@ -6,11 +6,12 @@ import (
 )
 
 const (
-	RsaBlockType         = "RSA PRIVATE KEY"
-	EcdsaBlockType       = "EC PRIVATE KEY"
-	PKCCS8BlockType      = "PRIVATE KEY"
-	DefaultKeyLength     = 2048
-	RecommendedKeyLength = 4096
+	RsaBlockType            = "RSA PRIVATE KEY"
+	EcdsaBlockType          = "EC PRIVATE KEY"
+	PKCCS8BlockType         = "PRIVATE KEY"
+	EncryptedPKCS8BlockType = "ENCRYPTED PRIVATE KEY"
+	DefaultKeyLength        = 2048
+	RecommendedKeyLength    = 4096
 )
 
 var _defaultMarshalOptions = &MarshalOptions{
@@ -29,11 +30,18 @@ type Generator interface {
 
 type MarshalOptions struct {
 	// Password can be nil, otherwise use it to encrypt the private key.
-	// If the IsPKCS8 is true, the Password will be ignored.
+	// If the IsPKCS8 is true, the private key is encrypted with PBES2,
+	// and encoded in "ENCRYPTED PRIVATE KEY" PEM blocks.
+	// Otherwise, the legacy PEM encryption (RFC 1423) is used, it is insecure
+	// and only kept for compatibility.
 	// See https://github.com/golang/go/commit/57af9745bfad2c20ed6842878e373d6c5b79285a.
 	Password []byte
 	// IsPKCS8 whether to convert the private key to PKCS #8, ASN.1 DER form.
 	IsPKCS8 bool
+	// KDF is the key derivation function of the PBES2 encryption, default is PBKDF2.
+	KDF KDF
+	// Cipher is the cipher of the PBES2 encryption, default is AES256CBC.
+	Cipher Cipher
 }
 
 // EncodeWithBlockType returns the PEM encoding of b with the given block type.
@@ -0,0 +1,111 @@
+package key
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+)
+
+var (
+	// ErrPasswordRequired is returned when parsing an encrypted private key without password.
+	ErrPasswordRequired = errors.New("key: password is required to decrypt the private key")
+	// ErrUnknownKeyType is returned when the private key is not in a known form.
+	ErrUnknownKeyType = errors.New("key: unknown private key type")
+)
+
+// ParsePrivateKeyFile reads the given file and parses a private key from it.
+// See ParsePrivateKey.
+func ParsePrivateKeyFile(fpath string, password []byte) (crypto.Signer, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data, password)
+}
+
+// ParsePrivateKey parses a private key from the given PEM or ASN.1 DER data.
+// PKCS #1, SEC 1 and PKCS #8 forms are supported. The password is used to
+// decrypt the PBES2 encrypted PKCS #8 and the legacy encrypted PEM blocks,
+// it can be nil if the private key is not encrypted.
+// And returns a crypto.Singer.
+func ParsePrivateKey(data, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return parseDERPrivateKey(data)
+	}
+
+	//nolint:staticcheck
+	if !x509.IsEncryptedPEMBlock(block) {
+		return parseBlockPrivateKey(block.Type, block.Bytes, password)
+	}
+	if len(password) == 0 {
+		return nil, ErrPasswordRequired
+	}
+	//nolint:staticcheck
+	der, err := x509.DecryptPEMBlock(block, password)
+	if err != nil {
+		//nolint:staticcheck
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, ErrIncorrectPassword
+		}
+		return nil, err
+	}
+	// a wrong password may pass the padding check of the legacy encryption,
+	// then the decrypted private key cannot be parsed
+	pkey, err := parseBlockPrivateKey(block.Type, der, password)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	return pkey, nil
+}
+
+// parseBlockPrivateKey parses the private key in the given form of the PEM block type.
+func parseBlockPrivateKey(blockType string, der, password []byte) (crypto.Signer, error) {
+	switch blockType {
+	case EncryptedPKCS8BlockType:
+		if len(password) == 0 {
+			return nil, ErrPasswordRequired
+		}
+		decrypted, err := DecryptPKCS8PrivateKey(der, password)
+		if err != nil {
+			return nil, err
+		}
+		return parsePKCS8PrivateKey(decrypted)
+	case RsaBlockType:
+		return x509.ParsePKCS1PrivateKey(der)
+	case EcdsaBlockType:
+		return x509.ParseECPrivateKey(der)
+	case PKCCS8BlockType:
+		return parsePKCS8PrivateKey(der)
+	default:
+		return parseDERPrivateKey(der)
+	}
+}
+
+// parseDERPrivateKey tries to parse the private key in PKCS #1, SEC 1 and PKCS #8 forms.
+func parseDERPrivateKey(der []byte) (crypto.Signer, error) {
+	if pkey, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return pkey, nil
+	}
+	if pkey, err := parsePKCS8PrivateKey(der); err == nil {
+		return pkey, nil
+	}
+	if pkey, err := x509.ParseECPrivateKey(der); err == nil {
+		return pkey, nil
+	}
+	return nil, ErrUnknownKeyType
+}
+
+func parsePKCS8PrivateKey(der []byte) (crypto.Signer, error) {
+	pkey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := pkey.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnknownKeyType
+	}
+	return signer, nil
+}
@@ -0,0 +1,410 @@
+package key
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF is the key derivation function used to encrypt a PKCS #8 private key.
+type KDF int
+
+const (
+	// PBKDF2 is PBKDF2 with HMAC-SHA256, see RFC 8018.
+	PBKDF2 KDF = iota
+	// Scrypt is scrypt, see RFC 7914.
+	Scrypt
+)
+
+// Cipher is the cipher used to encrypt a PKCS #8 private key.
+// Note that OpenSSL does not support the AES-GCM ciphers in PBES2,
+// use the AES-CBC ciphers for interoperability.
+type Cipher int
+
+const (
+	AES256CBC Cipher = iota
+	AES128CBC
+	AES256GCM
+	AES128GCM
+)
+
+const (
+	PBKDF2Iterations = 600000
+	ScryptN          = 1 << 14
+	ScryptR          = 8
+	ScryptP          = 1
+
+	_saltSize     = 16
+	_gcmNonceSize = 12
+	_gcmTagSize   = 16
+
+	// the limits of the key derivation parameters on decryption, so a crafted
+	// private key cannot take unbounded time or memory to decrypt
+	_maxPBKDF2Iterations = 5000000
+	_maxScryptN          = 1 << 20
+	_maxScryptR          = 32
+	_maxScryptP          = 16
+)
+
+var (
+	// ErrIncorrectPassword is returned when an encrypted private key can not be decrypted with the password.
+	ErrIncorrectPassword = errors.New("key: incorrect password")
+	// ErrUnsupportedEncryption is returned when an encrypted private key uses an unsupported algorithm.
+	ErrUnsupportedEncryption = errors.New("key: unsupported private key encryption algorithm")
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES128GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+	oidAES192GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}
+	oidAES256GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+// encryptedPrivateKeyInfo is the EncryptedPrivateKeyInfo structure, see RFC 5958, section 3.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+// MarshalEncryptedPKCS8PrivateKey converts a private key to PKCS #8, ASN.1 DER form,
+// and encrypts it with PBES2 using the given password, key derivation function and cipher.
+// And returns the private key encoded in PEM blocks.
+func MarshalEncryptedPKCS8PrivateKey(pkey any, password []byte, kdf KDF, c Cipher) ([]byte, error) {
+	b, err := x509.MarshalPKCS8PrivateKey(pkey)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := EncryptPKCS8PrivateKey(b, password, kdf, c)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeWithBlockType(eb, EncryptedPKCS8BlockType), nil
+}
+
+// EncryptPKCS8PrivateKey encrypts a PKCS #8, ASN.1 DER form private key with PBES2
+// using the given password, key derivation function and cipher.
+// And returns the EncryptedPrivateKeyInfo in ASN.1 DER form.
+func EncryptPKCS8PrivateKey(der, password []byte, kdf KDF, c Cipher) ([]byte, error) {
+	encAlg, encrypted, err := PBES2Encrypt(der, password, kdf, c)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     encAlg,
+		EncryptedData: encrypted,
+	})
+}
+
+// DecryptPKCS8PrivateKey decrypts an EncryptedPrivateKeyInfo in ASN.1 DER form with the password.
+// And returns the private key in PKCS #8, ASN.1 DER form.
+func DecryptPKCS8PrivateKey(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data"}
+	}
+	return PBES2Decrypt(info.Algorithm, info.EncryptedData, password)
+}
+
+// PBES2Encrypt encrypts the data with PBES2 using the given password, key derivation
+// function and cipher, see RFC 8018, section 6.2.
+// And returns the PBES2 algorithm identifier and the encrypted data.
+func PBES2Encrypt(data, password []byte, kdf KDF, c Cipher) (pkix.AlgorithmIdentifier, []byte, error) {
+	var alg pkix.AlgorithmIdentifier
+	encOID, keyLen, err := cipherParams(c)
+	if err != nil {
+		return alg, nil, err
+	}
+
+	salt := make([]byte, _saltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return alg, nil, err
+	}
+	var (
+		kdfAlg pkix.AlgorithmIdentifier
+		dk     []byte
+	)
+	switch kdf {
+	case PBKDF2:
+		dk = pbkdf2.Key(password, salt, PBKDF2Iterations, keyLen, sha256.New)
+		kdfAlg, err = newAlgorithmIdentifier(oidPBKDF2, pbkdf2Params{
+			Salt:           salt,
+			IterationCount: PBKDF2Iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+		})
+	case Scrypt:
+		dk, err = scrypt.Key(password, salt, ScryptN, ScryptR, ScryptP, keyLen)
+		if err != nil {
+			return alg, nil, err
+		}
+		kdfAlg, err = newAlgorithmIdentifier(oidScrypt, scryptParams{
+			Salt:                     salt,
+			CostParameter:            ScryptN,
+			BlockSize:                ScryptR,
+			ParallelizationParameter: ScryptP,
+		})
+	default:
+		return alg, nil, ErrUnsupportedEncryption
+	}
+	if err != nil {
+		return alg, nil, err
+	}
+
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		return alg, nil, err
+	}
+	var (
+		encAlg    pkix.AlgorithmIdentifier
+		encrypted []byte
+	)
+	if c == AES256GCM || c == AES128GCM {
+		nonce := make([]byte, _gcmNonceSize)
+		if _, err = rand.Read(nonce); err != nil {
+			return alg, nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return alg, nil, err
+		}
+		encrypted = aead.Seal(nil, nonce, data, nil)
+		encAlg, err = newAlgorithmIdentifier(encOID, gcmParams{Nonce: nonce, ICVLen: _gcmTagSize})
+		if err != nil {
+			return alg, nil, err
+		}
+	} else {
+		iv := make([]byte, aes.BlockSize)
+		if _, err = rand.Read(iv); err != nil {
+			return alg, nil, err
+		}
+		padding := aes.BlockSize - len(data)%aes.BlockSize
+		encrypted = make([]byte, len(data)+padding)
+		copy(encrypted, data)
+		for i := len(data); i < len(encrypted); i++ {
+			encrypted[i] = byte(padding)
+		}
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+		encAlg, err = newAlgorithmIdentifier(encOID, iv)
+		if err != nil {
+			return alg, nil, err
+		}
+	}
+
+	alg, err = newAlgorithmIdentifier(oidPBES2, pbes2Params{
+		KeyDerivationFunc: kdfAlg,
+		EncryptionScheme:  encAlg,
+	})
+	return alg, encrypted, err
+}
+
+// PBES2Decrypt decrypts the data with the PBES2 algorithm identifier and the password.
+// The key derivation parameters are limited, a PBKDF2 iteration count above
+// 5000000, or a scrypt N above 2^20, r above 32 or p above 16 is rejected with
+// ErrUnsupportedEncryption.
+func PBES2Decrypt(alg pkix.AlgorithmIdentifier, encrypted, password []byte) ([]byte, error) {
+	if !alg.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedEncryption
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	encOID := params.EncryptionScheme.Algorithm
+	var (
+		keyLen int
+		isGCM  bool
+	)
+	switch {
+	case encOID.Equal(oidAES128CBC):
+		keyLen = 16
+	case encOID.Equal(oidAES192CBC):
+		keyLen = 24
+	case encOID.Equal(oidAES256CBC):
+		keyLen = 32
+	case encOID.Equal(oidAES128GCM):
+		keyLen, isGCM = 16, true
+	case encOID.Equal(oidAES192GCM):
+		keyLen, isGCM = 24, true
+	case encOID.Equal(oidAES256GCM):
+		keyLen, isGCM = 32, true
+	default:
+		return nil, ErrUnsupportedEncryption
+	}
+
+	dk, err := deriveKey(params.KeyDerivationFunc, password, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		return nil, err
+	}
+
+	if isGCM {
+		var gp gcmParams
+		if _, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &gp); err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCMWithNonceSize(block, len(gp.Nonce))
+		if err != nil {
+			return nil, err
+		}
+		if gp.ICVLen != aead.Overhead() {
+			return nil, ErrUnsupportedEncryption
+		}
+		decrypted, err := aead.Open(nil, gp.Nonce, encrypted, nil)
+		if err != nil {
+			return nil, ErrIncorrectPassword
+		}
+		return decrypted, nil
+	}
+
+	var iv []byte
+	if _, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, ErrIncorrectPassword
+	}
+	decrypted := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted)
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrIncorrectPassword
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, ErrIncorrectPassword
+		}
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
+
+// deriveKey derives a key of the given length from the password with the key derivation function.
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keyLen int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, ErrUnsupportedEncryption
+		}
+		var h func() hash.Hash
+		switch {
+		case len(params.PRF.Algorithm) == 0 || params.PRF.Algorithm.Equal(oidHMACWithSHA1):
+			h = sha1.New
+		case params.PRF.Algorithm.Equal(oidHMACWithSHA256):
+			h = sha256.New
+		case params.PRF.Algorithm.Equal(oidHMACWithSHA384):
+			h = sha512.New384
+		case params.PRF.Algorithm.Equal(oidHMACWithSHA512):
+			h = sha512.New
+		default:
+			return nil, ErrUnsupportedEncryption
+		}
+		if params.IterationCount < 1 || params.IterationCount > _maxPBKDF2Iterations {
+			return nil, fmt.Errorf("%w: PBKDF2 iteration count %d is out of range [1, %d]",
+				ErrUnsupportedEncryption, params.IterationCount, _maxPBKDF2Iterations)
+		}
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keyLen, h), nil
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, ErrUnsupportedEncryption
+		}
+		if params.CostParameter > _maxScryptN || params.BlockSize > _maxScryptR || params.ParallelizationParameter > _maxScryptP {
+			return nil, fmt.Errorf("%w: scrypt parameters N=%d, r=%d, p=%d exceed N=%d, r=%d, p=%d",
+				ErrUnsupportedEncryption, params.CostParameter, params.BlockSize, params.ParallelizationParameter,
+				_maxScryptN, _maxScryptR, _maxScryptP)
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter,
+			params.BlockSize, params.ParallelizationParameter, keyLen)
+	default:
+		return nil, ErrUnsupportedEncryption
+	}
+}
+
+// cipherParams returns the OID and the key length of the cipher.
+func cipherParams(c Cipher) (asn1.ObjectIdentifier, int, error) {
+	switch c {
+	case AES256CBC:
+		return oidAES256CBC, 32, nil
+	case AES128CBC:
+		return oidAES128CBC, 16, nil
+	case AES256GCM:
+		return oidAES256GCM, 32, nil
+	case AES128GCM:
+		return oidAES128GCM, 16, nil
+	default:
+		return nil, 0, ErrUnsupportedEncryption
+	}
+}
+
+func newAlgorithmIdentifier(oid asn1.ObjectIdentifier, params any) (pkix.AlgorithmIdentifier, error) {
+	b, err := asn1.Marshal(params)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  oid,
+		Parameters: asn1.RawValue{FullBytes: b},
+	}, nil
+}
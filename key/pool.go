@@ -0,0 +1,392 @@
+package key
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	_defaultPoolDepth  = 8
+	_poolRetryInterval = time.Second
+	_cacheFileExt      = ".pem"
+)
+
+// PoolStats is the statistics of a Pool.
+// Available is the number of the keys that are ready to use.
+// Hits is the number of the keys taken from the pool or the cache.
+// Misses is the number of the keys generated on demand, as the pool is empty.
+// Generated is the number of the keys generated by the background workers.
+// Cached is the number of the keys loaded from the cache.
+// Errors is the number of the failures of the background workers.
+type PoolStats struct {
+	Available int
+	Hits      uint64
+	Misses    uint64
+	Generated uint64
+	Cached    uint64
+	Errors    uint64
+}
+
+// PoolOption defines optional parameters for initializing the Pool.
+type PoolOption interface {
+	apply(p *Pool)
+}
+
+// poolOptionFunc wraps a func, so it satisfies the PoolOption interface.
+type poolOptionFunc func(*Pool)
+
+func (fn poolOptionFunc) apply(p *Pool) {
+	fn(p)
+}
+
+// WithPoolDepth is used to set the number of the keys that are pre-generated, default is 8.
+func WithPoolDepth(depth int) PoolOption {
+	return poolOptionFunc(func(p *Pool) {
+		p.depth = depth
+	})
+}
+
+// WithPoolWorkers is used to set the number of the background workers,
+// default is runtime.GOMAXPROCS(0).
+func WithPoolWorkers(workers int) PoolOption {
+	return poolOptionFunc(func(p *Pool) {
+		p.workers = workers
+	})
+}
+
+// WithPoolCache is used to set the directory of the on-disk cache, it is
+// intended for test fixtures. The keys in the cache are loaded when the Pool
+// is created, and served before the pre-generated keys. The keys generated
+// by the Pool are saved in the cache as unencrypted PKCS #8 PEM files, until
+// the cache has the given size of keys. The directory should be dedicated to
+// the keys of one Generator, e.g. RSA 4096 bits, the keys of other types,
+// sizes or curves are skipped. The type of the keys is taken from the
+// configuration of RsaKey, EcdsaKey and Ed25519Key, for other Generators, it is
+// unknown until the first key is generated, so the cached keys are served
+// after that. The keys that cannot be marshaled to PKCS #8, e.g. the keys of
+// a crypto.Signer backed by a hardware token, are not saved.
+func WithPoolCache(dir string, size int) PoolOption {
+	return poolOptionFunc(func(p *Pool) {
+		p.cacheDir = dir
+		p.cacheSize = size
+	})
+}
+
+// Pool is a Generator that pre-generates the keys of the underlying Generator
+// in background goroutines. Gen takes a key from the pool, or generates a new
+// key if the pool is empty. Marshal is delegated to the underlying Generator.
+// The background workers stop when the context of the Pool is done or Close is
+// called, then Gen still serves the remaining keys.
+// A Pool is safe for concurrent use.
+type Pool struct {
+	g         Generator
+	depth     int
+	workers   int
+	cacheDir  string
+	cacheSize int
+
+	keys   chan crypto.Signer
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu          sync.Mutex
+	match       func(k crypto.Signer) bool // nil until the key type is known
+	unchecked   []crypto.Signer            // the cached keys of an unknown key type
+	cached      []crypto.Signer
+	cacheStored int
+
+	hits, misses, generated, loaded, errors atomic.Uint64
+}
+
+// NewPool returns a new Pool of the given Generator, and starts the background workers.
+func NewPool(ctx context.Context, g Generator, opts ...PoolOption) (*Pool, error) {
+	p := &Pool{
+		g:       g,
+		depth:   _defaultPoolDepth,
+		workers: runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+	if p.depth < 1 {
+		p.depth = 1
+	}
+	if p.workers < 1 {
+		p.workers = 1
+	}
+	p.match = keyTypeOf(g)
+	if len(p.cacheDir) > 0 {
+		if err := p.loadCache(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.keys = make(chan crypto.Signer, p.depth)
+	ctx, p.cancel = context.WithCancel(ctx)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+	return p, nil
+}
+
+// Gen takes a public and private key pair from the pool, if the pool is
+// empty, generates a new one.
+// And returns a crypto.Singer.
+func (p *Pool) Gen() (crypto.Signer, error) {
+	return p.GenContext(context.Background())
+}
+
+// GenContext is the same as Gen, but the key pair is generated by GenContext
+// if the pool is empty.
+func (p *Pool) GenContext(ctx context.Context) (crypto.Signer, error) {
+	if k, ok := p.take(); ok {
+		p.hits.Add(1)
+		return k, nil
+	}
+
+	p.misses.Add(1)
+	k, err := GenContext(ctx, p.g)
+	if err != nil {
+		return nil, err
+	}
+	p.checkCache(k)
+	p.store(k)
+	return k, nil
+}
+
+// Marshal converts a private key to ASN.1 DER form with the underlying Generator.
+// And returns the private key encoded in PEM blocks.
+func (p *Pool) Marshal(pkey crypto.Signer, opts *MarshalOptions) ([]byte, error) {
+	return p.g.Marshal(pkey, opts)
+}
+
+// Stats returns the statistics of the Pool.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	available := len(p.cached)
+	p.mu.Unlock()
+
+	return PoolStats{
+		Available: available + len(p.keys),
+		Hits:      p.hits.Load(),
+		Misses:    p.misses.Load(),
+		Generated: p.generated.Load(),
+		Cached:    p.loaded.Load(),
+		Errors:    p.errors.Load(),
+	}
+}
+
+// Close stops the background workers, and waits for them to exit.
+// A key generation in progress cannot be interrupted, see GenContext, so it
+// may still finish in the background after Close returns, and its key pair
+// is discarded.
+func (p *Pool) Close() error {
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+// take takes a key from the cache or the pool without blocking.
+func (p *Pool) take() (crypto.Signer, bool) {
+	p.mu.Lock()
+	if len(p.cached) > 0 {
+		k := p.cached[0]
+		p.cached = p.cached[1:]
+		p.mu.Unlock()
+		return k, true
+	}
+	p.mu.Unlock()
+
+	select {
+	case k := <-p.keys:
+		return k, true
+	default:
+		return nil, false
+	}
+}
+
+// work generates keys until the context is done.
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		k, err := GenContext(ctx, p.g)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.errors.Add(1)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(_poolRetryInterval):
+			}
+			continue
+		}
+		p.checkCache(k)
+		select {
+		case <-ctx.Done():
+			return
+		case p.keys <- k:
+			p.generated.Add(1)
+			p.store(k)
+		}
+	}
+}
+
+// loadCache loads the keys in the cache directory, the files that cannot be
+// parsed or have a different key type, size or curve are skipped. If the key
+// type is unknown, the keys are checked by checkCache.
+func (p *Pool) loadCache() error {
+	if err := os.MkdirAll(p.cacheDir, 0o700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(p.cacheDir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), _cacheFileExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		k, err := ParsePrivateKeyFile(filepath.Join(p.cacheDir, name), nil)
+		if err != nil {
+			continue
+		}
+		p.unchecked = append(p.unchecked, k)
+	}
+	if p.match != nil {
+		p.filterCache()
+	}
+	return nil
+}
+
+// checkCache takes the key type from the given key generated by the underlying
+// Generator, if it is unknown, and checks the loaded keys of the cache.
+func (p *Pool) checkCache(k crypto.Signer) {
+	if len(p.cacheDir) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.match != nil {
+		return
+	}
+	p.match = func(c crypto.Signer) bool { return sameKeyType(c, k) }
+	p.filterCache()
+}
+
+// filterCache moves the unchecked keys of the same key type to the cached keys,
+// p.mu must be held or the Pool is not shared yet.
+func (p *Pool) filterCache() {
+	for _, k := range p.unchecked {
+		if p.match(k) {
+			p.cached = append(p.cached, k)
+			p.loaded.Add(1)
+		}
+	}
+	p.unchecked = nil
+	p.cacheStored = len(p.cached)
+}
+
+// keyTypeOf returns a function that reports whether a key has the key type,
+// size or curve of the given Generator, it returns nil if the Generator is not
+// one of the built-in Generators.
+func keyTypeOf(g Generator) func(k crypto.Signer) bool {
+	switch kg := g.(type) {
+	case *RsaKey:
+		return func(k crypto.Signer) bool {
+			rk, ok := k.(*rsa.PrivateKey)
+			return ok && rk.N.BitLen() == kg.bits
+		}
+	case *EcdsaKey:
+		return func(k crypto.Signer) bool {
+			ek, ok := k.(*ecdsa.PrivateKey)
+			return ok && ek.Curve == kg.curve
+		}
+	case *Ed25519Key:
+		return func(k crypto.Signer) bool {
+			_, ok := k.(ed25519.PrivateKey)
+			return ok
+		}
+	}
+	return nil
+}
+
+// sameKeyType reports whether the given keys have the same type, and the
+// same size for RSA keys or the same curve for ECDSA keys.
+func sameKeyType(a, b crypto.Signer) bool {
+	switch ka := a.(type) {
+	case *rsa.PrivateKey:
+		kb, ok := b.(*rsa.PrivateKey)
+		return ok && ka.N.BitLen() == kb.N.BitLen()
+	case *ecdsa.PrivateKey:
+		kb, ok := b.(*ecdsa.PrivateKey)
+		return ok && ka.Curve == kb.Curve
+	case ed25519.PrivateKey:
+		_, ok := b.(ed25519.PrivateKey)
+		return ok
+	}
+	return false
+}
+
+// store saves the given key in the cache, if the cache is enabled and not full.
+// The failures are ignored, the cache is best-effort, the keys that cannot be
+// marshaled to PKCS #8 are not counted in the size of the cache.
+func (p *Pool) store(k crypto.Signer) {
+	if len(p.cacheDir) == 0 {
+		return
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		return
+	}
+	pub, err := x509.MarshalPKIXPublicKey(k.Public())
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	if p.cacheStored >= p.cacheSize {
+		p.mu.Unlock()
+		return
+	}
+	p.cacheStored++
+	p.mu.Unlock()
+
+	sum := sha256.Sum256(pub)
+	fpath := filepath.Join(p.cacheDir, hex.EncodeToString(sum[:8])+_cacheFileExt)
+
+	f, err := os.CreateTemp(p.cacheDir, ".key*")
+	if err != nil {
+		return
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	_, err = f.Write(EncodeWithBlockType(der, PKCCS8BlockType))
+	if cerr := f.Close(); err != nil || cerr != nil {
+		return
+	}
+	_ = os.Rename(f.Name(), fpath)
+}
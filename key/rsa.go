@@ -37,6 +37,9 @@ func (g *RsaKey) Marshal(pkey crypto.Signer, opts *MarshalOptions) ([]byte, erro
 	if !opts.IsPKCS8 {
 		return g.MarshalPKCS1PrivateKey(pkey.(*rsa.PrivateKey), opts.Password)
 	}
+	if len(opts.Password) > 0 {
+		return MarshalEncryptedPKCS8PrivateKey(pkey, opts.Password, opts.KDF, opts.Cipher)
+	}
 	return g.MarshalPKCS8PrivateKey(pkey)
 }
 
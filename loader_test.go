@@ -0,0 +1,140 @@
+package crt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	testPass := []byte("123456")
+	rsaKey, err := key.NewRsaKey(0).Gen()
+	assert.NoError(t, err)
+	ecKey, err := key.NewEcdsaKey(nil).Gen()
+	assert.NoError(t, err)
+	edKey, err := key.NewEd25519Key().Gen()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		title string
+		g     key.Generator
+		pkey  interface {
+			Equal(x crypto.PrivateKey) bool
+		}
+		opts     *key.MarshalOptions
+		password []byte
+	}{
+		{"PKCS#1", key.NewRsaKey(0), rsaKey.(*rsa.PrivateKey), nil, nil},
+		{"PKCS#1 encrypted", key.NewRsaKey(0), rsaKey.(*rsa.PrivateKey), &key.MarshalOptions{Password: testPass}, testPass},
+		{"PKCS#8 RSA", key.NewRsaKey(0), rsaKey.(*rsa.PrivateKey), &key.MarshalOptions{IsPKCS8: true}, nil},
+		{"SEC1", key.NewEcdsaKey(nil), ecKey.(*ecdsa.PrivateKey), nil, nil},
+		{"SEC1 encrypted", key.NewEcdsaKey(nil), ecKey.(*ecdsa.PrivateKey), &key.MarshalOptions{Password: testPass}, testPass},
+		{"PKCS#8 ECDSA", key.NewEcdsaKey(nil), ecKey.(*ecdsa.PrivateKey), &key.MarshalOptions{IsPKCS8: true}, nil},
+		{"PKCS#8 Ed25519", key.NewEd25519Key(), edKey.(ed25519.PrivateKey), nil, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			raw, err := v.g.Marshal(v.pkey.(crypto.Signer), v.opts)
+			assert.NoError(t, err)
+
+			parsed, err := key.ParsePrivateKey(raw, v.password)
+			assert.NoError(t, err)
+			assert.True(t, v.pkey.Equal(parsed))
+
+			block, _ := pem.Decode(raw)
+			if v.password == nil {
+				parsed, err = key.ParsePrivateKey(block.Bytes, nil)
+				assert.NoError(t, err)
+				assert.True(t, v.pkey.Equal(parsed))
+			}
+		})
+	}
+
+	t.Run("should return error: password is required", func(t *testing.T) {
+		raw, err := key.NewEcdsaKey(nil).Marshal(ecKey, &key.MarshalOptions{Password: testPass})
+		assert.NoError(t, err)
+		_, err = key.ParsePrivateKey(raw, nil)
+		assert.Equal(t, key.ErrPasswordRequired, err)
+	})
+
+	t.Run("should return error: unknown private key type", func(t *testing.T) {
+		_, err := key.ParsePrivateKey([]byte("invalid"), nil)
+		assert.Equal(t, key.ErrUnknownKeyType, err)
+	})
+}
+
+func TestNewFromCA(t *testing.T) {
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+	caRaw, caKeyRaw, err := g.Create(NewCACert())
+	assert.NoError(t, err)
+
+	t.Run("should load the CA pair from files", func(t *testing.T) {
+		caPath := "testdata/loader-ca.crt"
+		caKeyPath := "testdata/loader-ca.key"
+		filelist = append(filelist, caPath, caKeyPath)
+		w, err := generator.NewFileWriterFromPaths(caPath, caKeyPath)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Write(caRaw, caKeyRaw))
+		assert.NoError(t, w.Close())
+
+		loaded, err := generator.NewFromCAFiles(caPath, caKeyPath, nil,
+			generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+		assert.NoError(t, err)
+		ca, _ := loaded.CA()
+		assert.Equal(t, "CRT GENERATOR CA", ca.Subject.CommonName)
+
+		certRaw, _, err := loaded.Create(NewServerCert())
+		assert.NoError(t, err)
+		parsed, err := parseCertBytes(certRaw)
+		assert.NoError(t, err)
+		assert.NoError(t, parsed.CheckSignatureFrom(ca))
+		reset()
+	})
+
+	t.Run("should load the CA pair from DER bytes", func(t *testing.T) {
+		certBlock, _ := pem.Decode(caRaw)
+		keyBlock, _ := pem.Decode(caKeyRaw)
+		_, err := generator.NewFromCA(certBlock.Bytes, keyBlock.Bytes, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error: private key does not match", func(t *testing.T) {
+		_, otherKeyRaw, err := g.Create(NewCACert())
+		assert.NoError(t, err)
+		_, err = generator.NewFromCA(caRaw, otherKeyRaw, nil)
+		assert.Equal(t, "x509: private key does not match the public key of the certificate", err.Error())
+	})
+
+	t.Run("should return error: certificate is not a CA certificate", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		certRaw, keyRaw, err := g.Create(NewServerCert())
+		assert.NoError(t, err)
+		_, err = generator.NewFromCA(certRaw, keyRaw, nil)
+		assert.Equal(t, "x509: certificate is not a CA certificate", err.Error())
+	})
+
+	t.Run("should parse the certificate chain", func(t *testing.T) {
+		g := createEcdsaGenWithCA(t)
+		certRaw, _, err := g.CreateWithOptions(NewServerCert(), generator.CreateOptions{AppendCA: true})
+		assert.NoError(t, err)
+		certs, err := generator.ParseCertificates(certRaw)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(certs))
+		assert.True(t, certs[1].IsCA)
+
+		_, err = generator.ParseCertificate([]byte("invalid"))
+		assert.Error(t, err)
+		_, err = generator.ParseCertificate(caKeyRaw)
+		assert.Equal(t, "x509: no certificate is found", err.Error())
+	})
+}
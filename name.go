@@ -0,0 +1,242 @@
+package crt
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	oidCommonName         = asn1.ObjectIdentifier{2, 5, 4, 3}
+	oidSerialNumber       = asn1.ObjectIdentifier{2, 5, 4, 5}
+	oidCountry            = asn1.ObjectIdentifier{2, 5, 4, 6}
+	oidLocality           = asn1.ObjectIdentifier{2, 5, 4, 7}
+	oidProvince           = asn1.ObjectIdentifier{2, 5, 4, 8}
+	oidStreetAddress      = asn1.ObjectIdentifier{2, 5, 4, 9}
+	oidOrganization       = asn1.ObjectIdentifier{2, 5, 4, 10}
+	oidOrganizationalUnit = asn1.ObjectIdentifier{2, 5, 4, 11}
+	oidPostalCode         = asn1.ObjectIdentifier{2, 5, 4, 17}
+)
+
+// _attributeTypes maps the attribute type names of a distinguished name to OIDs.
+var _attributeTypes = map[string]asn1.ObjectIdentifier{
+	"CN":           oidCommonName,
+	"SERIALNUMBER": oidSerialNumber,
+	"C":            oidCountry,
+	"L":            oidLocality,
+	"ST":           oidProvince,
+	"S":            oidProvince,
+	"STREET":       oidStreetAddress,
+	"O":            oidOrganization,
+	"OU":           oidOrganizationalUnit,
+	"POSTALCODE":   oidPostalCode,
+	"SN":           {2, 5, 4, 4},
+	"SURNAME":      {2, 5, 4, 4},
+	"T":            {2, 5, 4, 12},
+	"TITLE":        {2, 5, 4, 12},
+	"GN":           {2, 5, 4, 42},
+	"GIVENNAME":    {2, 5, 4, 42},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+	"UID":          {0, 9, 2342, 19200300, 100, 1, 1},
+	"E":            {1, 2, 840, 113549, 1, 9, 1},
+	"EMAILADDRESS": {1, 2, 840, 113549, 1, 9, 1},
+}
+
+// ParseDN parses a distinguished name string into a pkix.Name.
+// Both the RFC 4514 form, e.g. "CN=foo,O=bar,C=US", and the OpenSSL
+// form, e.g. "/C=US/O=bar/CN=foo", are supported. The attribute type
+// can be a name (case-insensitive) or a dotted OID, e.g. "2.5.4.3=foo".
+// The attributes that have no field in pkix.Name are set to the ExtraNames.
+func ParseDN(dn string) (pkix.Name, error) {
+	var name pkix.Name
+
+	dn = strings.TrimSpace(dn)
+	if dn == "" {
+		return name, nil
+	}
+	separators := ",;+"
+	openssl := strings.HasPrefix(dn, "/")
+	if openssl {
+		dn = dn[1:]
+		separators = "/+"
+	}
+
+	var attrs []pkix.AttributeTypeAndValue
+	for len(dn) > 0 {
+		attr, rest, err := parseAttribute(dn, separators)
+		if err != nil {
+			return name, err
+		}
+		attrs = append(attrs, attr)
+		dn = rest
+	}
+	if !openssl {
+		// the RFC 4514 form starts with the last RDN of the sequence
+		for i, j := 0, len(attrs)-1; i < j; i, j = i+1, j-1 {
+			attrs[i], attrs[j] = attrs[j], attrs[i]
+		}
+	}
+
+	for _, v := range attrs {
+		value := v.Value.(string)
+		switch {
+		case v.Type.Equal(oidCommonName):
+			if name.CommonName != "" {
+				return name, errors.New("crt: duplicate CN in distinguished name")
+			}
+			name.CommonName = value
+		case v.Type.Equal(oidSerialNumber):
+			if name.SerialNumber != "" {
+				return name, errors.New("crt: duplicate SERIALNUMBER in distinguished name")
+			}
+			name.SerialNumber = value
+		case v.Type.Equal(oidCountry):
+			name.Country = append(name.Country, value)
+		case v.Type.Equal(oidLocality):
+			name.Locality = append(name.Locality, value)
+		case v.Type.Equal(oidProvince):
+			name.Province = append(name.Province, value)
+		case v.Type.Equal(oidStreetAddress):
+			name.StreetAddress = append(name.StreetAddress, value)
+		case v.Type.Equal(oidOrganization):
+			name.Organization = append(name.Organization, value)
+		case v.Type.Equal(oidOrganizationalUnit):
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case v.Type.Equal(oidPostalCode):
+			name.PostalCode = append(name.PostalCode, value)
+		default:
+			name.ExtraNames = append(name.ExtraNames, v)
+		}
+	}
+	return name, nil
+}
+
+// parseAttribute parses the first "type=value" attribute of the given string.
+// And returns the attribute and the rest of the string after the separator.
+func parseAttribute(s, separators string) (pkix.AttributeTypeAndValue, string, error) {
+	var attr pkix.AttributeTypeAndValue
+
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		return attr, "", fmt.Errorf("crt: missing '=' in distinguished name %q", s)
+	}
+	typ := strings.TrimSpace(s[:eq])
+	oid, err := parseAttributeType(typ)
+	if err != nil {
+		return attr, "", err
+	}
+	attr.Type = oid
+
+	s = strings.TrimLeft(s[eq+1:], " ")
+	if strings.HasPrefix(s, "#") {
+		end := strings.IndexAny(s, separators)
+		if end < 0 {
+			end = len(s)
+		}
+		value, err := parseHexValue(strings.TrimSpace(s[1:end]))
+		if err != nil {
+			return attr, "", err
+		}
+		attr.Value = value
+		return attr, trimSeparator(s[end:]), nil
+	}
+
+	var (
+		value     []byte
+		escapedTo int // the length of value up to the last escaped character
+		quoted    bool
+		i         int
+	)
+	if strings.HasPrefix(s, `"`) {
+		quoted = true
+		i = 1
+	}
+	for ; i < len(s); i++ {
+		ch := s[i]
+		if quoted && ch == '"' {
+			quoted = false
+			escapedTo = len(value)
+			continue
+		}
+		if !quoted && strings.IndexByte(separators, ch) >= 0 {
+			break
+		}
+		if ch != '\\' {
+			value = append(value, ch)
+			continue
+		}
+		if i+1 >= len(s) {
+			return attr, "", fmt.Errorf("crt: invalid escape in distinguished name %q", s)
+		}
+		if b, err := hex.DecodeString(s[i+1 : min(i+3, len(s))]); err == nil && len(b) == 1 {
+			value = append(value, b[0])
+			i += 2
+		} else {
+			value = append(value, s[i+1])
+			i++
+		}
+		escapedTo = len(value)
+	}
+	if quoted {
+		return attr, "", fmt.Errorf("crt: unterminated quote in distinguished name %q", s)
+	}
+
+	// trim the trailing spaces that are not escaped
+	end := len(value)
+	for end > escapedTo && value[end-1] == ' ' {
+		end--
+	}
+	attr.Value = string(value[:end])
+	return attr, trimSeparator(s[i:]), nil
+}
+
+// parseAttributeType parses an attribute type name or a dotted OID.
+func parseAttributeType(typ string) (asn1.ObjectIdentifier, error) {
+	if oid, ok := _attributeTypes[strings.ToUpper(typ)]; ok {
+		return oid, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(strings.ToUpper(typ), "OID."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("crt: unknown attribute type %q in distinguished name", typ)
+	}
+	oid := make(asn1.ObjectIdentifier, 0, len(parts))
+	for _, v := range parts {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("crt: unknown attribute type %q in distinguished name", typ)
+		}
+		oid = append(oid, n)
+	}
+	return oid, nil
+}
+
+// parseHexValue parses the hex encoded ASN.1 DER value of an attribute, the
+// value must be a string type.
+func parseHexValue(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("crt: invalid hex value %q in distinguished name", s)
+	}
+	var raw asn1.RawValue
+	rest, err := asn1.Unmarshal(b, &raw)
+	if err != nil || len(rest) > 0 || raw.Class != asn1.ClassUniversal || raw.IsCompound {
+		return "", fmt.Errorf("crt: invalid hex value %q in distinguished name", s)
+	}
+	switch raw.Tag {
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String, asn1.TagT61String, asn1.TagNumericString:
+		return string(raw.Bytes), nil
+	default:
+		return "", fmt.Errorf("crt: unsupported hex value %q in distinguished name", s)
+	}
+}
+
+func trimSeparator(s string) string {
+	if len(s) > 0 {
+		s = s[1:]
+	}
+	return strings.TrimLeft(s, " ")
+}
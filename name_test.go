@@ -0,0 +1,75 @@
+package crt_test
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+)
+
+func TestParseDN(t *testing.T) {
+	oidDC := asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 25}
+
+	tests := []struct {
+		title    string
+		input    string
+		expected pkix.Name
+	}{
+		{"empty", "", pkix.Name{}},
+		{"RFC 4514 form", "CN=foo,OU=dev+OU=ops,O=bar,L=Shanghai,ST=SH,C=CN", pkix.Name{
+			CommonName:         "foo",
+			OrganizationalUnit: []string{"ops", "dev"},
+			Organization:       []string{"bar"},
+			Locality:           []string{"Shanghai"},
+			Province:           []string{"SH"},
+			Country:            []string{"CN"},
+		}},
+		{"OpenSSL form", "/C=US/ST=CA/O=bar/CN=foo", pkix.Name{
+			CommonName:   "foo",
+			Organization: []string{"bar"},
+			Province:     []string{"CA"},
+			Country:      []string{"US"},
+		}},
+		{"escaped and quoted values", `cn = foo\, bar\20 , o="baz, inc.", street=1 Main St\2c, postalCode=200000`, pkix.Name{
+			CommonName:    "foo, bar ",
+			Organization:  []string{"baz, inc."},
+			StreetAddress: []string{"1 Main St,"},
+			PostalCode:    []string{"200000"},
+		}},
+		{"extra names and OIDs", "CN=foo,DC=example,DC=com,2.5.4.5=SN-1,1.2.3.4=#0c03626172", pkix.Name{
+			CommonName:   "foo",
+			SerialNumber: "SN-1",
+			ExtraNames: []pkix.AttributeTypeAndValue{
+				{Type: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: "bar"},
+				{Type: oidDC, Value: "com"},
+				{Type: oidDC, Value: "example"},
+			},
+		}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			got, err := ParseDN(v.input)
+			assert.NoError(t, err)
+			assert.Equal(t, v.expected, got)
+		})
+	}
+
+	errs := []string{
+		"CN",
+		"XX=foo",
+		"CN=foo,CN=bar",
+		`CN="foo`,
+		"CN=#zz",
+		`CN=foo\`,
+	}
+	for _, v := range errs {
+		t.Run("should return error: "+v, func(t *testing.T) {
+			_, err := ParseDN(v)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package ocsp defines an RFC 6960 OCSP responder backed by the CA of a generator.Generator.
+package ocsp
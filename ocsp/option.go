@@ -0,0 +1,38 @@
+package ocsp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"time"
+)
+
+// Option defines optional parameters for initializing the responder
+// structure.
+type Option interface {
+	apply(r *Responder)
+}
+
+// optionFunc wraps a func, so it satisfies the Option interface.
+type optionFunc func(*Responder)
+
+func (fn optionFunc) apply(r *Responder) {
+	fn(r)
+}
+
+// WithResponderCert is used to set a delegated OCSP signing certificate and
+// its private key. The certificate must be issued by the CA of the Generator
+// and have the x509.ExtKeyUsageOCSPSigning extended key usage.
+func WithResponderCert(cert *x509.Certificate, key crypto.Signer) Option {
+	return optionFunc(func(r *Responder) {
+		r.cert = cert
+		r.key = key
+	})
+}
+
+// WithValidity is used to set the validity of the responses, it is the
+// duration between the ThisUpdate and NextUpdate of a response.
+func WithValidity(validity time.Duration) Option {
+	return optionFunc(func(r *Responder) {
+		r.validity = validity
+	})
+}
@@ -0,0 +1,188 @@
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xocsp "golang.org/x/crypto/ocsp"
+
+	"github.com/shipengqi/crt/generator"
+)
+
+const (
+	_defaultValidity = time.Hour
+	_maxRequestSize  = 10 * 1024
+
+	responseContentType = "application/ocsp-response"
+)
+
+var _ http.Handler = &Responder{}
+
+// Responder is an http.Handler that answers OCSP requests for the
+// certificates issued by a generator.Generator. Both GET and POST requests
+// are supported, a GET request carries the base64 encoded request in the
+// URL path, so the Responder should be mounted at the root of the server
+// or with http.StripPrefix.
+//
+// A certificate revoked by the current CA of the Generator is reported as
+// revoked, a certificate issued by the current CA is reported as good, any
+// other certificate, e.g. a certificate with the same serial number issued by
+// another CA of the Generator, is reported as unknown. The certificates are
+// looked up in the records of the Generator, which keep the latest 1024
+// certificates by default, see generator.WithIssuedLimit. The times of the
+// responses are taken from the Clock of the Generator.
+type Responder struct {
+	g        *generator.Generator
+	cert     *x509.Certificate
+	key      crypto.Signer
+	validity time.Duration
+}
+
+// NewResponder creates a new Responder for the given Generator.
+// By default, the responses are signed by the CA of the Generator.
+func NewResponder(g *generator.Generator, opts ...Option) *Responder {
+	r := &Responder{
+		g:        g,
+		validity: _defaultValidity,
+	}
+	r.withOptions(opts...)
+
+	return r
+}
+
+// ServeHTTP implements http.Handler interface.
+func (r *Responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var (
+		raw []byte
+		err error
+	)
+	switch req.Method {
+	case http.MethodGet:
+		raw, err = decodeGetRequest(req.URL)
+	case http.MethodPost:
+		raw, err = io.ReadAll(io.LimitReader(req.Body, _maxRequestSize))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeResponse(w, xocsp.MalformedRequestErrorResponse)
+		return
+	}
+
+	resp, err := r.Respond(raw)
+	if err != nil {
+		writeResponse(w, xocsp.InternalErrorErrorResponse)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+// Respond answers the given ASN.1 DER encoded OCSP request.
+// And returns the ASN.1 DER encoded OCSP response.
+func (r *Responder) Respond(raw []byte) ([]byte, error) {
+	ocspReq, err := xocsp.ParseRequest(raw)
+	if err != nil {
+		return xocsp.MalformedRequestErrorResponse, nil
+	}
+
+	ca, caKey := r.g.CA()
+	if ca == nil || caKey == nil {
+		return xocsp.UnauthorizedErrorResponse, nil
+	}
+	ok, err := matchIssuer(ocspReq, ca)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return xocsp.UnauthorizedErrorResponse, nil
+	}
+
+	now := r.g.Now().UTC().Truncate(time.Minute)
+	tmpl := xocsp.Response{
+		Status:       xocsp.Unknown,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(r.validity),
+		IssuerHash:   ocspReq.HashAlgorithm,
+	}
+
+	responderCert, signer := r.cert, r.key
+	if responderCert != nil && signer != nil {
+		// embed the delegated certificate, so the client can verify the response
+		tmpl.Certificate = responderCert
+	} else {
+		responderCert = ca
+		signer, ok = caKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("ocsp: CA private key does not implement crypto.Signer")
+		}
+	}
+	if revoked, ok := r.g.IsRevokedBy(ca, ocspReq.SerialNumber); ok {
+		tmpl.Status = xocsp.Revoked
+		tmpl.RevokedAt = revoked.RevokedAt
+		tmpl.RevocationReason = revoked.ReasonCode
+	} else if _, ok = r.g.IssuedBy(ca, ocspReq.SerialNumber); ok {
+		tmpl.Status = xocsp.Good
+	}
+
+	return xocsp.CreateResponse(ca, responderCert, tmpl, signer)
+}
+
+// withOptions set options for the Responder.
+func (r *Responder) withOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+}
+
+// decodeGetRequest decodes the base64 encoded OCSP request in the URL path,
+// see RFC 6960, appendix A.1.
+func decodeGetRequest(u *url.URL) ([]byte, error) {
+	p := u.EscapedPath()
+	p = strings.TrimPrefix(p, "/")
+	p, err := url.PathUnescape(p)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(p)
+}
+
+// matchIssuer reports whether the issuer of the given OCSP request is the CA.
+func matchIssuer(req *xocsp.Request, ca *x509.Certificate) (bool, error) {
+	if !req.HashAlgorithm.Available() {
+		return false, nil
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(ca.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false, err
+	}
+
+	h := req.HashAlgorithm.New()
+	h.Write(ca.RawSubject)
+	nameHash := h.Sum(nil)
+	h.Reset()
+	h.Write(spki.PublicKey.RightAlign())
+	keyHash := h.Sum(nil)
+
+	return bytes.Equal(nameHash, req.IssuerNameHash) && bytes.Equal(keyHash, req.IssuerKeyHash), nil
+}
+
+func writeResponse(w http.ResponseWriter, resp []byte) {
+	w.Header().Set("Content-Type", responseContentType)
+	_, _ = w.Write(resp)
+}
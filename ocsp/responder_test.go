@@ -0,0 +1,191 @@
+package ocsp_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	xocsp "golang.org/x/crypto/ocsp"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+	"github.com/shipengqi/crt/ocsp"
+)
+
+func TestResponder(t *testing.T) {
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+	_, _, err := g.CreateWithOptions(crt.NewCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	ca, _ := g.CA()
+
+	srv := httptest.NewServer(ocsp.NewResponder(g))
+	defer srv.Close()
+
+	leaf := issue(t, g, crt.NewServerCert(
+		crt.WithOCSPServers(srv.URL),
+		crt.WithIssuingCertificateURLs(srv.URL+"/ca.crt"),
+	))
+	assert.Equal(t, []string{srv.URL}, leaf.OCSPServer)
+	assert.Equal(t, []string{srv.URL + "/ca.crt"}, leaf.IssuingCertificateURL)
+
+	t.Run("should return good status", func(t *testing.T) {
+		resp := post(t, srv.URL, leaf, ca)
+		parsed, err := xocsp.ParseResponseForCert(resp, leaf, ca)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Good, parsed.Status)
+		assert.Equal(t, leaf.SerialNumber, parsed.SerialNumber)
+		assert.Equal(t, parsed.ThisUpdate.Add(time.Hour), parsed.NextUpdate)
+	})
+
+	t.Run("should return unknown status", func(t *testing.T) {
+		unknown := *leaf
+		unknown.SerialNumber = big.NewInt(42)
+		resp := post(t, srv.URL, &unknown, ca)
+		parsed, err := xocsp.ParseResponseForCert(resp, &unknown, ca)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Unknown, parsed.Status)
+	})
+
+	t.Run("should return revoked status with GET request", func(t *testing.T) {
+		revoked := issue(t, g, crt.NewServerCert())
+		revokedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+		assert.NoError(t, g.Revoke(revoked.SerialNumber, generator.ReasonKeyCompromise, revokedAt))
+
+		req, err := xocsp.CreateRequest(revoked, ca, nil)
+		assert.NoError(t, err)
+		res, err := http.Get(srv.URL + "/" + url.PathEscape(base64.StdEncoding.EncodeToString(req)))
+		assert.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		assert.Equal(t, "application/ocsp-response", res.Header.Get("Content-Type"))
+		resp, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		parsed, err := xocsp.ParseResponseForCert(resp, revoked, ca)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Revoked, parsed.Status)
+		assert.Equal(t, revokedAt, parsed.RevokedAt)
+		assert.Equal(t, generator.ReasonKeyCompromise, parsed.RevocationReason)
+	})
+
+	t.Run("should return unauthorized with an unknown issuer", func(t *testing.T) {
+		other := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+		_, _, err := other.CreateWithOptions(crt.NewCACert(), generator.CreateOptions{UseAsCA: true})
+		assert.NoError(t, err)
+		otherCA, _ := other.CA()
+		otherLeaf := issue(t, other, crt.NewServerCert())
+
+		resp := post(t, srv.URL, otherLeaf, otherCA)
+		_, err = xocsp.ParseResponse(resp, otherCA)
+		assert.Equal(t, xocsp.ResponseError{Status: xocsp.Unauthorized}, err)
+	})
+
+	t.Run("should return malformed with an invalid request", func(t *testing.T) {
+		res, err := http.Post(srv.URL, "application/ocsp-request", bytes.NewReader([]byte("invalid")))
+		assert.NoError(t, err)
+		defer func() { _ = res.Body.Close() }()
+		resp, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		_, err = xocsp.ParseResponse(resp, ca)
+		assert.Equal(t, xocsp.ResponseError{Status: xocsp.Malformed}, err)
+	})
+
+	t.Run("should sign with the delegated certificate", func(t *testing.T) {
+		certRaw, keyRaw, err := g.Create(crt.New(
+			crt.WithCN("OCSP Responder"),
+			crt.WithKeyUsage(x509.KeyUsageDigitalSignature),
+			crt.WithExtKeyUsages(x509.ExtKeyUsageOCSPSigning),
+		))
+		assert.NoError(t, err)
+		block, _ := pem.Decode(certRaw)
+		responderCert, err := x509.ParseCertificate(block.Bytes)
+		assert.NoError(t, err)
+		block, _ = pem.Decode(keyRaw)
+		responderKey, err := x509.ParseECPrivateKey(block.Bytes)
+		assert.NoError(t, err)
+
+		delegated := httptest.NewServer(ocsp.NewResponder(g,
+			ocsp.WithResponderCert(responderCert, responderKey),
+			ocsp.WithValidity(time.Minute),
+		))
+		defer delegated.Close()
+
+		resp := post(t, delegated.URL, leaf, ca)
+		parsed, err := xocsp.ParseResponseForCert(resp, leaf, ca)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Good, parsed.Status)
+		assert.Equal(t, responderCert.Raw, parsed.Certificate.Raw)
+		assert.Equal(t, responderKey.Public().(*ecdsa.PublicKey), parsed.Certificate.PublicKey)
+		assert.Equal(t, parsed.ThisUpdate.Add(time.Minute), parsed.NextUpdate)
+	})
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestResponderIssuer(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithClock(fixedClock(now)))
+	_, _, err := g.CreateWithOptions(crt.NewCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	leaf := issue(t, g, crt.NewServerCert())
+	assert.NoError(t, g.Revoke(leaf.SerialNumber, generator.ReasonKeyCompromise, time.Time{}))
+
+	_, _, err = g.CreateWithOptions(crt.NewIntermediateCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	inter, _ := g.CA()
+
+	srv := httptest.NewServer(ocsp.NewResponder(g))
+	defer srv.Close()
+
+	t.Run("should return unknown status for the serial number of another CA", func(t *testing.T) {
+		resp := post(t, srv.URL, leaf, inter)
+		parsed, err := xocsp.ParseResponse(resp, inter)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Unknown, parsed.Status)
+		assert.Equal(t, now.Truncate(time.Minute), parsed.ThisUpdate)
+	})
+
+	t.Run("should return good status for the current CA", func(t *testing.T) {
+		interLeaf := issue(t, g, crt.NewServerCert())
+		resp := post(t, srv.URL, interLeaf, inter)
+		parsed, err := xocsp.ParseResponseForCert(resp, interLeaf, inter)
+		assert.NoError(t, err)
+		assert.Equal(t, xocsp.Good, parsed.Status)
+	})
+}
+
+func issue(t *testing.T, g *generator.Generator, c *crt.Certificate) *x509.Certificate {
+	t.Helper()
+
+	certRaw, _, err := g.Create(c)
+	assert.NoError(t, err)
+	block, _ := pem.Decode(certRaw)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	return cert
+}
+
+func post(t *testing.T, server string, cert, issuer *x509.Certificate) []byte {
+	t.Helper()
+
+	req, err := xocsp.CreateRequest(cert, issuer, nil)
+	assert.NoError(t, err)
+	res, err := http.Post(server, "application/ocsp-request", bytes.NewReader(req))
+	assert.NoError(t, err)
+	defer func() { _ = res.Body.Close() }()
+	resp, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	return resp
+}
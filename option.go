@@ -2,7 +2,11 @@ package crt
 
 import (
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
 	"net"
+	"net/url"
 	"time"
 )
 
@@ -47,6 +51,43 @@ func WithIPs(ip ...net.IP) Option {
 	})
 }
 
+// WithCRLDistributionPoints is used to set the CRL Distribution Points of the certificate.
+func WithCRLDistributionPoints(urls ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.crlDPs = urls
+	})
+}
+
+// WithOCSPServers is used to set the OCSP server URLs of the Authority
+// Information Access extension of the certificate.
+func WithOCSPServers(urls ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.ocspServers = urls
+	})
+}
+
+// WithIssuingCertificateURLs is used to set the CA issuer URLs of the Authority
+// Information Access extension of the certificate.
+func WithIssuingCertificateURLs(urls ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.issuingURLs = urls
+	})
+}
+
+// WithURIs is used to set the URI values of the certificate.
+func WithURIs(uri ...*url.URL) Option {
+	return optionFunc(func(c *Certificate) {
+		c.uris = uri
+	})
+}
+
+// WithEmailAddresses is used to set the email address values of the certificate.
+func WithEmailAddresses(email ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.emails = email
+	})
+}
+
 // WithKeyUsage is used to set the x509.KeyUsage of the certificate.
 func WithKeyUsage(keyUsage ...x509.KeyUsage) Option {
 	return optionFunc(func(c *Certificate) {
@@ -77,6 +118,168 @@ func WithExtKeyUsages(extKeyUsage ...x509.ExtKeyUsage) Option {
 	})
 }
 
+// WithUnknownExtKeyUsages is used to set the extended key usages that are not
+// defined by x509.ExtKeyUsage, e.g. the vendor-specific OIDs.
+func WithUnknownExtKeyUsages(oid ...asn1.ObjectIdentifier) Option {
+	return optionFunc(func(c *Certificate) {
+		c.unknownEKUs = oid
+	})
+}
+
+// WithPolicies is used to set the policies of the Certificate Policies extension.
+func WithPolicies(policy ...Policy) Option {
+	return optionFunc(func(c *Certificate) {
+		c.policies = policy
+	})
+}
+
+// WithExtensions is used to add raw extensions to the certificate, the criticality
+// is set by pkix.Extension.Critical. An extension overrides the extension with the
+// same OID that is generated from the other options.
+func WithExtensions(ext ...pkix.Extension) Option {
+	return optionFunc(func(c *Certificate) {
+		c.extensions = ext
+	})
+}
+
+// WithNotBefore is used to set the absolute start of the validity period of the certificate.
+// If WithNotAfter is not used, the validity period ends at the given time plus the validity.
+func WithNotBefore(t time.Time) Option {
+	return optionFunc(func(c *Certificate) {
+		c.notBefore = t
+	})
+}
+
+// WithNotAfter is used to set the absolute end of the validity period of the certificate.
+func WithNotAfter(t time.Time) Option {
+	return optionFunc(func(c *Certificate) {
+		c.notAfter = t
+	})
+}
+
+// WithBackdate is used to move the start of the validity period backward by the
+// given duration, to tolerate the clock skew of the hosts. The end of the
+// validity period is not changed. It is ignored if WithNotBefore is used.
+func WithBackdate(d time.Duration) Option {
+	return optionFunc(func(c *Certificate) {
+		c.backdate = d
+	})
+}
+
+// WithClock is used to set the Clock that provides the current time of the
+// validity period of the certificate.
+func WithClock(clock Clock) Option {
+	return optionFunc(func(c *Certificate) {
+		c.clock = clock
+	})
+}
+
+// WithSerialNumber is used to set a fixed serial number of the certificate,
+// e.g. to reproduce a certificate from a bug report.
+func WithSerialNumber(n *big.Int) Option {
+	return optionFunc(func(c *Certificate) {
+		c.serials = fixedSerialNumber{n: n}
+	})
+}
+
+// WithSerialNumberGenerator is used to set the SerialNumberGenerator of the
+// certificate, e.g. NewSequentialSerialNumber or NewFileSerialNumber.
+func WithSerialNumberGenerator(g SerialNumberGenerator) Option {
+	return optionFunc(func(c *Certificate) {
+		c.serials = g
+	})
+}
+
+// WithMaxPathLen is used to set the maximum number of intermediate CA certificates
+// that may follow the CA certificate in a chain. Zero means that no intermediate CA
+// certificate may follow, a negative value means that the path length is unlimited.
+// It is ignored if the certificate is not CA type.
+func WithMaxPathLen(n int) Option {
+	return optionFunc(func(c *Certificate) {
+		if n < 0 {
+			c.maxPathLen = -1
+			c.maxPathLenZero = false
+			return
+		}
+		c.maxPathLen = n
+		c.maxPathLenZero = n == 0
+	})
+}
+
+// WithPermittedDNSDomains is used to set the permitted DNS domains of the Name Constraints.
+// A domain with a leading period only matches its subdomains, e.g. ".example.com".
+// It is ignored if the certificate is not CA type.
+func WithPermittedDNSDomains(domain ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.permittedDNSDomains = domain
+	})
+}
+
+// WithExcludedDNSDomains is used to set the excluded DNS domains of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithExcludedDNSDomains(domain ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.excludedDNSDomains = domain
+	})
+}
+
+// WithPermittedIPRanges is used to set the permitted IP ranges of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithPermittedIPRanges(ipNet ...*net.IPNet) Option {
+	return optionFunc(func(c *Certificate) {
+		c.permittedIPRanges = ipNet
+	})
+}
+
+// WithExcludedIPRanges is used to set the excluded IP ranges of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithExcludedIPRanges(ipNet ...*net.IPNet) Option {
+	return optionFunc(func(c *Certificate) {
+		c.excludedIPRanges = ipNet
+	})
+}
+
+// WithPermittedEmailAddresses is used to set the permitted email addresses of the Name Constraints.
+// A value can be a mailbox, e.g. "foo@example.com", or a domain, e.g. "example.com".
+// It is ignored if the certificate is not CA type.
+func WithPermittedEmailAddresses(email ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.permittedEmailAddresses = email
+	})
+}
+
+// WithExcludedEmailAddresses is used to set the excluded email addresses of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithExcludedEmailAddresses(email ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.excludedEmailAddresses = email
+	})
+}
+
+// WithPermittedURIDomains is used to set the permitted URI domains of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithPermittedURIDomains(domain ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.permittedURIDomains = domain
+	})
+}
+
+// WithExcludedURIDomains is used to set the excluded URI domains of the Name Constraints.
+// It is ignored if the certificate is not CA type.
+func WithExcludedURIDomains(domain ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.excludedURIDomains = domain
+	})
+}
+
+// WithNameConstraintsCritical is used to mark the Name Constraints extension as critical.
+// It is ignored if the certificate is not CA type.
+func WithNameConstraintsCritical() Option {
+	return optionFunc(func(c *Certificate) {
+		c.nameConstraintsCritical = true
+	})
+}
+
 // WithOrganizations is used to set the Organization values of the certificate.
 func WithOrganizations(org ...string) Option {
 	return optionFunc(func(c *Certificate) {
@@ -84,11 +287,92 @@ func WithOrganizations(org ...string) Option {
 	})
 }
 
+// WithOrganizationalUnits is used to set the OrganizationalUnit values of the certificate.
+func WithOrganizationalUnits(ou ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.orgUnits = ou
+	})
+}
+
+// WithCountries is used to set the Country values of the certificate.
+func WithCountries(country ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.countries = country
+	})
+}
+
+// WithProvinces is used to set the Province values of the certificate.
+func WithProvinces(province ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.provinces = province
+	})
+}
+
+// WithLocalities is used to set the Locality values of the certificate.
+func WithLocalities(locality ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.localities = locality
+	})
+}
+
+// WithStreetAddresses is used to set the StreetAddress values of the certificate.
+func WithStreetAddresses(street ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.streets = street
+	})
+}
+
+// WithPostalCodes is used to set the PostalCode values of the certificate.
+func WithPostalCodes(code ...string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.postalCodes = code
+	})
+}
+
+// WithSubjectSerialNumber is used to set the SerialNumber attribute of the subject,
+// it is not the serial number of the certificate.
+func WithSubjectSerialNumber(sn string) Option {
+	return optionFunc(func(c *Certificate) {
+		c.subjectSerial = sn
+	})
+}
+
+// WithExtraNames is used to set the extra attributes of the subject,
+// e.g. the attributes that have no field in pkix.Name.
+func WithExtraNames(names ...pkix.AttributeTypeAndValue) Option {
+	return optionFunc(func(c *Certificate) {
+		c.extraNames = names
+	})
+}
+
+// WithSubject is used to set the whole subject of the certificate, it
+// replaces the values set by WithCN, WithOrganizations, WithCountries, etc.
+// The name can be parsed from a string by ParseDN.
+func WithSubject(name pkix.Name) Option {
+	return optionFunc(func(c *Certificate) {
+		c.cn = name.CommonName
+		c.organizations = name.Organization
+		c.orgUnits = name.OrganizationalUnit
+		c.countries = name.Country
+		c.provinces = name.Province
+		c.localities = name.Locality
+		c.streets = name.StreetAddress
+		c.postalCodes = name.PostalCode
+		c.subjectSerial = name.SerialNumber
+		c.extraNames = name.ExtraNames
+	})
+}
+
 // WithCAType is used to set the CA certificate type.
 func WithCAType() Option {
 	return withType(_caType)
 }
 
+// WithIntermediateCAType is used to set the Intermediate CA certificate type.
+func WithIntermediateCAType() Option {
+	return withType(_intermediateCAType)
+}
+
 // WithServerType is used to set the Server certificate type.
 func WithServerType() Option {
 	return withType(_serverType)
@@ -2,7 +2,10 @@ package crt_test
 
 import (
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"net"
+	"net/url"
 	"testing"
 	"time"
 
@@ -144,3 +147,83 @@ func TestWithOrganizations(t *testing.T) {
 	assert.Equal(t, 1, len(parsed.Subject.Organization))
 	assert.Equal(t, "test", parsed.Subject.Organization[0])
 }
+
+func TestWithSubjectOptions(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	oidUID := asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+	t.Run("should set all the subject attributes", func(t *testing.T) {
+		cert := New(
+			WithServerType(),
+			WithCN("foo"),
+			WithOrganizations("org"),
+			WithOrganizationalUnits("ou1", "ou2"),
+			WithCountries("US"),
+			WithProvinces("CA"),
+			WithLocalities("San Francisco"),
+			WithStreetAddresses("1 Main St"),
+			WithPostalCodes("94105"),
+			WithSubjectSerialNumber("SN-1"),
+			WithExtraNames(pkix.AttributeTypeAndValue{Type: oidUID, Value: "uid-1"}),
+		)
+		created, _, err := g.Create(cert)
+		assert.Nil(t, err)
+		parsed, err := parseCertBytes(created)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "foo", parsed.Subject.CommonName)
+		assert.Equal(t, []string{"org"}, parsed.Subject.Organization)
+		assert.Equal(t, []string{"ou1", "ou2"}, parsed.Subject.OrganizationalUnit)
+		assert.Equal(t, []string{"US"}, parsed.Subject.Country)
+		assert.Equal(t, []string{"CA"}, parsed.Subject.Province)
+		assert.Equal(t, []string{"San Francisco"}, parsed.Subject.Locality)
+		assert.Equal(t, []string{"1 Main St"}, parsed.Subject.StreetAddress)
+		assert.Equal(t, []string{"94105"}, parsed.Subject.PostalCode)
+		assert.Equal(t, "SN-1", parsed.Subject.SerialNumber)
+		found := false
+		for _, v := range parsed.Subject.Names {
+			if v.Type.Equal(oidUID) {
+				found = true
+				assert.Equal(t, "uid-1", v.Value)
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("should replace the subject", func(t *testing.T) {
+		name, err := ParseDN("CN=bar,O=org2,C=CN")
+		assert.Nil(t, err)
+		cert := New(
+			WithServerType(),
+			WithCN("foo"),
+			WithLocalities("Shanghai"),
+			WithSubject(name),
+		)
+		created, _, err := g.Create(cert)
+		assert.Nil(t, err)
+		parsed, err := parseCertBytes(created)
+		assert.Nil(t, err)
+		assert.Equal(t, "CN=bar,O=org2,C=CN", parsed.Subject.String())
+	})
+}
+
+func TestWithURIsAndEmailAddresses(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	u, err := url.Parse("https://example.com/users/1")
+	assert.Nil(t, err)
+
+	cert := New(
+		WithClientType(),
+		WithURIs(u, u),
+		WithEmailAddresses("foo@example.com", "foo@example.com", "bar@example.com"),
+	)
+	assert.True(t, cert.HasSANs())
+
+	created, _, err := g.Create(cert)
+	assert.Nil(t, err)
+	parsed, err := parseCertBytes(created)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(parsed.URIs))
+	assert.Equal(t, "https://example.com/users/1", parsed.URIs[0].String())
+	assert.Equal(t, []string{"foo@example.com", "bar@example.com"}, parsed.EmailAddresses)
+}
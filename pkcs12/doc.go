@@ -0,0 +1,2 @@
+// Package pkcs12 defines an encoder of the password protected PKCS #12 (.p12/.pfx) files.
+package pkcs12
@@ -0,0 +1,84 @@
+package pkcs12
+
+import (
+	"hash"
+	"math/big"
+	"unicode/utf16"
+)
+
+var one = big.NewInt(1)
+
+// pbkdf derives a key of the given size with the PKCS #12 key derivation function,
+// see RFC 7292, appendix B.2.
+// u is the output size of the hash function, v is its block size.
+func pbkdf(h func() hash.Hash, u, v int, salt, password []byte, r int, id byte, size int) []byte {
+	D := make([]byte, v)
+	for i := range D {
+		D[i] = id
+	}
+	S := fill(salt, v)
+	P := fill(password, v)
+	I := append(S, P...)
+
+	c := (size + u - 1) / u
+	A := make([]byte, 0, c*u)
+	for i := 1; i <= c; i++ {
+		hh := h()
+		hh.Write(D)
+		hh.Write(I)
+		Ai := hh.Sum(nil)
+		for j := 1; j < r; j++ {
+			hh.Reset()
+			hh.Write(Ai)
+			Ai = hh.Sum(nil)
+		}
+		A = append(A, Ai...)
+
+		if i < c {
+			B := fill(Ai, v)
+			Bbi := new(big.Int).SetBytes(B)
+			Bbi.Add(Bbi, one)
+			mod := new(big.Int).Lsh(one, uint(v*8))
+			for j := 0; j < len(I)/v; j++ {
+				Ij := new(big.Int).SetBytes(I[j*v : (j+1)*v])
+				Ij.Add(Ij, Bbi)
+				Ij.Mod(Ij, mod)
+				b := Ij.Bytes()
+				block := I[j*v : (j+1)*v]
+				for k := range block {
+					block[k] = 0
+				}
+				copy(block[v-len(b):], b)
+			}
+		}
+	}
+	return A[:size]
+}
+
+// fill concatenates copies of b to a length of v*ceil(len(b)/v).
+func fill(b []byte, v int) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	out := make([]byte, v*((len(b)+v-1)/v))
+	for i := 0; i < len(out); i += len(b) {
+		copy(out[i:], b)
+	}
+	return out
+}
+
+// bmpString returns the string encoded as a BMPString.
+func bmpString(s string) []byte {
+	runes := utf16.Encode([]rune(s))
+	out := make([]byte, 0, len(runes)*2)
+	for _, r := range runes {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return out
+}
+
+// bmpPassword returns the password encoded as a null terminated BMPString,
+// see RFC 7292, appendix B.1.
+func bmpPassword(password []byte) []byte {
+	return append(bmpString(string(password)), 0, 0)
+}
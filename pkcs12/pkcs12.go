@@ -0,0 +1,344 @@
+package pkcs12
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+
+	"github.com/shipengqi/crt/key"
+)
+
+const (
+	MacIterations = 2048
+
+	_macSaltSize = 16
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidJavaTrustStore      = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+	oidAnyExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// TrustStoreEntry is a trusted certificate entry of a PKCS #12 trust store.
+// If the FriendlyName is empty, the CommonName of the certificate is used.
+type TrustStoreEntry struct {
+	Cert         *x509.Certificate
+	FriendlyName string
+}
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// Encode encodes the private key and the certificate chain into a password
+// protected PKCS #12 file. The first certificate of the chain must be the
+// certificate of the private key, the rest are the CA certificates.
+// If the friendlyName is empty, the CommonName of the certificate is used.
+//
+// The private key and the certificates are encrypted with PBES2 (PBKDF2 with
+// HMAC-SHA256 and AES-256-CBC), the integrity is protected by HMAC-SHA256.
+// It is supported by OpenSSL 1.1.1+ and Java 8u301+.
+func Encode(pkey crypto.PrivateKey, chain []*x509.Certificate, password []byte, friendlyName string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("pkcs12: certificate is not provided")
+	}
+	leaf := chain[0]
+	if friendlyName == "" {
+		friendlyName = leaf.Subject.CommonName
+	}
+	localKeyID := sha1.Sum(leaf.Raw)
+
+	keyAttrs, err := newAttributes(friendlyName, localKeyID[:])
+	if err != nil {
+		return nil, err
+	}
+	certBags := make([]safeBag, 0, len(chain))
+	bag, err := newCertBag(leaf, keyAttrs)
+	if err != nil {
+		return nil, err
+	}
+	certBags = append(certBags, bag)
+	for _, v := range chain[1:] {
+		attrs, err := newAttributes(v.Subject.CommonName, nil)
+		if err != nil {
+			return nil, err
+		}
+		bag, err = newCertBag(v, attrs)
+		if err != nil {
+			return nil, err
+		}
+		certBags = append(certBags, bag)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(pkey)
+	if err != nil {
+		return nil, err
+	}
+	shrouded, err := key.EncryptPKCS8PrivateKey(der, password, key.PBKDF2, key.AES256CBC)
+	if err != nil {
+		return nil, err
+	}
+	keyBag := safeBag{
+		ID:         oidPKCS8ShroudedKeyBag,
+		Value:      explicit(shrouded),
+		Attributes: keyAttrs,
+	}
+
+	certsInfo, err := newEncryptedContentInfo(certBags, password)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo, err := newDataContentInfo([]safeBag{keyBag})
+	if err != nil {
+		return nil, err
+	}
+	return encodePFX([]contentInfo{certsInfo, keyInfo}, password)
+}
+
+// EncodeTrustStore encodes the certificates into a password protected PKCS #12
+// trust store, the certificates are marked as trusted for Java.
+func EncodeTrustStore(certs []*x509.Certificate, password []byte) ([]byte, error) {
+	entries := make([]TrustStoreEntry, 0, len(certs))
+	for _, v := range certs {
+		entries = append(entries, TrustStoreEntry{Cert: v})
+	}
+	return EncodeTrustStoreEntries(entries, password)
+}
+
+// EncodeTrustStoreEntries encodes the trusted certificate entries into a
+// password protected PKCS #12 trust store, the certificates are marked as
+// trusted for Java.
+func EncodeTrustStoreEntries(entries []TrustStoreEntry, password []byte) ([]byte, error) {
+	trusted, err := asn1.Marshal(oidAnyExtendedKeyUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	bags := make([]safeBag, 0, len(entries))
+	for _, v := range entries {
+		name := v.FriendlyName
+		if name == "" {
+			name = v.Cert.Subject.CommonName
+		}
+		attrs, err := newAttributes(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, pkcs12Attribute{
+			ID:    oidJavaTrustStore,
+			Value: asn1.RawValue{Tag: asn1.TagSet, Class: asn1.ClassUniversal, IsCompound: true, Bytes: trusted},
+		})
+		bag, err := newCertBag(v.Cert, attrs)
+		if err != nil {
+			return nil, err
+		}
+		bags = append(bags, bag)
+	}
+
+	info, err := newEncryptedContentInfo(bags, password)
+	if err != nil {
+		return nil, err
+	}
+	return encodePFX([]contentInfo{info}, password)
+}
+
+// encodePFX encodes the authenticated safe into a PFX, and computes its MAC.
+func encodePFX(authenticatedSafe []contentInfo, password []byte) ([]byte, error) {
+	content, err := asn1.Marshal(authenticatedSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, _macSaltSize)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+	macKey := pbkdf(sha256.New, sha256.Size, sha256.BlockSize, salt, bmpPassword(password), MacIterations, 3, sha256.Size)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(content)
+
+	authSafe, err := newContentInfo(oidDataContentType, content)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pfxPdu{
+		Version:  3,
+		AuthSafe: authSafe,
+		MacData: macData{
+			Mac: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+				Digest:    mac.Sum(nil),
+			},
+			MacSalt:    salt,
+			Iterations: MacIterations,
+		},
+	})
+}
+
+// newDataContentInfo returns a data ContentInfo of the SafeContents.
+func newDataContentInfo(bags []safeBag) (contentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return newContentInfo(oidDataContentType, safeContents)
+}
+
+// newEncryptedContentInfo returns an encryptedData ContentInfo of the SafeContents,
+// the SafeContents is encrypted with PBES2.
+func newEncryptedContentInfo(bags []safeBag, password []byte) (contentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	alg, encrypted, err := key.PBES2Encrypt(safeContents, password, key.PBKDF2, key.AES256CBC)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	b, err := asn1.Marshal(encryptedData{
+		Version: 0,
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                oidDataContentType,
+			ContentEncryptionAlgorithm: alg,
+			EncryptedContent:           encrypted,
+		},
+	})
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{
+		ContentType: oidEncryptedDataContentType,
+		Content:     explicit(b),
+	}, nil
+}
+
+// newContentInfo returns a ContentInfo of the given content type, the content
+// is wrapped in an OCTET STRING.
+func newContentInfo(contentType asn1.ObjectIdentifier, content []byte) (contentInfo, error) {
+	octets, err := asn1.Marshal(content)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{
+		ContentType: contentType,
+		Content:     explicit(octets),
+	}, nil
+}
+
+func newCertBag(cert *x509.Certificate, attrs []pkcs12Attribute) (safeBag, error) {
+	b, err := asn1.Marshal(certBag{
+		ID:   oidCertTypeX509,
+		Data: cert.Raw,
+	})
+	if err != nil {
+		return safeBag{}, err
+	}
+	return safeBag{
+		ID:         oidCertBag,
+		Value:      explicit(b),
+		Attributes: attrs,
+	}, nil
+}
+
+// newAttributes returns the friendlyName and localKeyId attributes,
+// the empty attributes are skipped.
+func newAttributes(friendlyName string, localKeyID []byte) ([]pkcs12Attribute, error) {
+	var attrs []pkcs12Attribute
+	if friendlyName != "" {
+		b, err := asn1.Marshal(asn1.RawValue{
+			Class: asn1.ClassUniversal,
+			Tag:   30, // BMPString
+			Bytes: bmpString(friendlyName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, pkcs12Attribute{
+			ID:    oidFriendlyName,
+			Value: asn1.RawValue{Tag: asn1.TagSet, Class: asn1.ClassUniversal, IsCompound: true, Bytes: b},
+		})
+	}
+	if len(localKeyID) > 0 {
+		b, err := asn1.Marshal(localKeyID)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, pkcs12Attribute{
+			ID:    oidLocalKeyID,
+			Value: asn1.RawValue{Tag: asn1.TagSet, Class: asn1.ClassUniversal, IsCompound: true, Bytes: b},
+		})
+	}
+	return attrs, nil
+}
+
+// explicit wraps the DER encoded value in an explicit context-specific tag 0.
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      der,
+	}
+}
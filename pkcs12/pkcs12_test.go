@@ -0,0 +1,174 @@
+package pkcs12
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt/key"
+)
+
+func TestPBKDF(t *testing.T) {
+	salt, _ := hex.DecodeString("0A58CF64530D823F")
+	got := pbkdf(sha1.New, sha1.Size, 64, salt, bmpPassword([]byte("smeg")), 1, 1, 24)
+	assert.Equal(t, "8aaae6297b6cb04642ab5b077851284eb7128f1a2a7fbca3", hex.EncodeToString(got))
+}
+
+func TestEncode(t *testing.T) {
+	password := []byte("123456")
+	ca, caKey := newCert(t, "CA", nil, nil)
+	leaf, leafKey := newCert(t, "leaf", ca, caKey)
+
+	t.Run("should contain the private key and the certificate chain", func(t *testing.T) {
+		der, err := Encode(leafKey, []*x509.Certificate{leaf, ca}, password, "alias")
+		assert.NoError(t, err)
+
+		bags := decode(t, der, password)
+		assert.Equal(t, 3, len(bags))
+
+		assert.True(t, bags[0].ID.Equal(oidCertBag))
+		assert.Equal(t, leaf.Raw, certData(t, bags[0]))
+		assert.Equal(t, "alias", friendlyName(t, bags[0]))
+		assert.True(t, bags[1].ID.Equal(oidCertBag))
+		assert.Equal(t, ca.Raw, certData(t, bags[1]))
+		assert.Equal(t, "CA", friendlyName(t, bags[1]))
+
+		assert.True(t, bags[2].ID.Equal(oidPKCS8ShroudedKeyBag))
+		assert.Equal(t, "alias", friendlyName(t, bags[2]))
+		pkcs8, err := key.DecryptPKCS8PrivateKey(bags[2].Value.Bytes, password)
+		assert.NoError(t, err)
+		parsed, err := x509.ParsePKCS8PrivateKey(pkcs8)
+		assert.NoError(t, err)
+		assert.True(t, leafKey.Equal(parsed))
+	})
+
+	t.Run("should contain the trusted certificates", func(t *testing.T) {
+		der, err := EncodeTrustStoreEntries([]TrustStoreEntry{{Cert: ca, FriendlyName: "root"}, {Cert: leaf}}, password)
+		assert.NoError(t, err)
+
+		bags := decode(t, der, password)
+		assert.Equal(t, 2, len(bags))
+		assert.Equal(t, "root", friendlyName(t, bags[0]))
+		assert.Equal(t, ca.Raw, certData(t, bags[0]))
+		assert.Equal(t, "leaf", friendlyName(t, bags[1]))
+		for _, v := range bags {
+			found := false
+			for _, attr := range v.Attributes {
+				found = found || attr.ID.Equal(oidJavaTrustStore)
+			}
+			assert.True(t, found)
+		}
+	})
+
+	t.Run("should return error without certificate", func(t *testing.T) {
+		_, err := Encode(leafKey, nil, password, "")
+		assert.Error(t, err)
+	})
+}
+
+// decode verifies the MAC of the PFX, and returns all the safe bags.
+func decode(t *testing.T, der, password []byte) []safeBag {
+	t.Helper()
+
+	var pfx pfxPdu
+	_, err := asn1.Unmarshal(der, &pfx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pfx.Version)
+
+	var content []byte
+	_, err = asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &content)
+	assert.NoError(t, err)
+	macKey := pbkdf(sha256.New, sha256.Size, sha256.BlockSize, pfx.MacData.MacSalt,
+		bmpPassword(password), pfx.MacData.Iterations, 3, sha256.Size)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(content)
+	assert.Equal(t, pfx.MacData.Mac.Digest, mac.Sum(nil))
+
+	var infos []contentInfo
+	_, err = asn1.Unmarshal(content, &infos)
+	assert.NoError(t, err)
+
+	var bags []safeBag
+	for _, v := range infos {
+		var safeContents []byte
+		if v.ContentType.Equal(oidEncryptedDataContentType) {
+			var ed encryptedData
+			_, err = asn1.Unmarshal(v.Content.Bytes, &ed)
+			assert.NoError(t, err)
+			safeContents, err = key.PBES2Decrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm,
+				ed.EncryptedContentInfo.EncryptedContent, password)
+			assert.NoError(t, err)
+		} else {
+			_, err = asn1.Unmarshal(v.Content.Bytes, &safeContents)
+			assert.NoError(t, err)
+		}
+		var sc []safeBag
+		_, err = asn1.Unmarshal(safeContents, &sc)
+		assert.NoError(t, err)
+		bags = append(bags, sc...)
+	}
+	return bags
+}
+
+func certData(t *testing.T, bag safeBag) []byte {
+	t.Helper()
+
+	var cb certBag
+	_, err := asn1.Unmarshal(bag.Value.Bytes, &cb)
+	assert.NoError(t, err)
+	return cb.Data
+}
+
+func friendlyName(t *testing.T, bag safeBag) string {
+	t.Helper()
+
+	for _, v := range bag.Attributes {
+		if !v.ID.Equal(oidFriendlyName) {
+			continue
+		}
+		var raw asn1.RawValue
+		_, err := asn1.Unmarshal(v.Value.Bytes, &raw)
+		assert.NoError(t, err)
+		runes := make([]rune, 0, len(raw.Bytes)/2)
+		for i := 0; i+1 < len(raw.Bytes); i += 2 {
+			runes = append(runes, rune(raw.Bytes[i])<<8|rune(raw.Bytes[i+1]))
+		}
+		return string(runes)
+	}
+	return ""
+}
+
+func newCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	pkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, pkey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pkey.Public(), parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, pkey
+}
@@ -0,0 +1,64 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestPKCS12Writer(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	ca, _ := g.CA()
+	p12Path := "testdata/server.p12"
+
+	t.Run("should write the PKCS#12 key store", func(t *testing.T) {
+		filelist = append(filelist, p12Path)
+		w, err := generator.NewPKCS12WriterFromPath(p12Path, []byte("123456"), generator.PKCS12Options{
+			FriendlyName: "server",
+			CACerts:      []*x509.Certificate{ca},
+		})
+		assert.NoError(t, err)
+		err = g.CreateAndWrite(w, NewServerCert())
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(p12Path)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data)
+		reset()
+	})
+
+	t.Run("should write the PKCS#12 trust store", func(t *testing.T) {
+		filelist = append(filelist, p12Path)
+		w, err := generator.NewPKCS12WriterFromPath(p12Path, []byte("123456"), generator.PKCS12Options{
+			TrustStore: true,
+		})
+		assert.NoError(t, err)
+		err = g.CreateAndWrite(w, NewCACert())
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(p12Path)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data)
+		reset()
+	})
+
+	t.Run("should return error: password is required", func(t *testing.T) {
+		filelist = append(filelist, p12Path)
+		w, err := generator.NewPKCS12WriterFromPath(p12Path, []byte("123456"), generator.PKCS12Options{})
+		assert.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		certRaw, keyRaw, err := g.CreateWithOptions(NewServerCert(), generator.CreateOptions{
+			KeyOpts: &key.MarshalOptions{IsPKCS8: true, Password: []byte("654321")},
+		})
+		assert.NoError(t, err)
+		err = w.Write(certRaw, keyRaw)
+		assert.Equal(t, key.ErrPasswordRequired, err)
+		reset()
+	})
+}
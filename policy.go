@@ -0,0 +1,103 @@
+package crt
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+const _maxExplicitText = 200
+
+var (
+	oidExtensionCertificatePolicies = asn1.ObjectIdentifier{2, 5, 29, 32}
+	oidPolicyQualifierCPS           = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 1}
+	oidPolicyQualifierUserNotice    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 2}
+)
+
+// Policy defines a certificate policy of the Certificate Policies extension,
+// see RFC 5280, Section 4.2.1.4.
+// OID is the policy identifier.
+// CPSURI is the optional URI of the Certification Practice Statement, it must be ASCII.
+// UserNotice is the optional explicit text of the user notice qualifier, up to 200 characters.
+type Policy struct {
+	OID        asn1.ObjectIdentifier
+	CPSURI     string
+	UserNotice string
+}
+
+// policyInformation is the ASN.1 structure of PolicyInformation.
+type policyInformation struct {
+	Policy     asn1.ObjectIdentifier
+	Qualifiers []policyQualifierInfo `asn1:"optional,omitempty"`
+}
+
+// policyQualifierInfo is the ASN.1 structure of PolicyQualifierInfo.
+type policyQualifierInfo struct {
+	PolicyQualifierID asn1.ObjectIdentifier
+	Qualifier         asn1.RawValue
+}
+
+// userNotice is the ASN.1 structure of UserNotice, the noticeRef is not supported.
+type userNotice struct {
+	ExplicitText string `asn1:"utf8"`
+}
+
+// marshalPolicies returns the Certificate Policies extension of the given policies.
+func marshalPolicies(policies []Policy) (pkix.Extension, error) {
+	infos := make([]policyInformation, 0, len(policies))
+	for _, p := range policies {
+		info := policyInformation{Policy: p.OID}
+		if len(p.CPSURI) > 0 {
+			if !isIA5String(p.CPSURI) {
+				return pkix.Extension{}, fmt.Errorf("crt: CPS URI %q of policy %s is not a valid IA5String", p.CPSURI, p.OID)
+			}
+			info.Qualifiers = append(info.Qualifiers, policyQualifierInfo{
+				PolicyQualifierID: oidPolicyQualifierCPS,
+				Qualifier:         asn1.RawValue{Tag: asn1.TagIA5String, Bytes: []byte(p.CPSURI)},
+			})
+		}
+		if len(p.UserNotice) > 0 {
+			// explicitText is limited to 200 characters, see RFC 5280, section 4.2.1.4
+			if !utf8.ValidString(p.UserNotice) || utf8.RuneCountInString(p.UserNotice) > _maxExplicitText {
+				return pkix.Extension{}, fmt.Errorf("crt: user notice of policy %s is not a valid UTF8String of up to %d characters", p.OID, _maxExplicitText)
+			}
+			notice, err := asn1.Marshal(userNotice{ExplicitText: p.UserNotice})
+			if err != nil {
+				return pkix.Extension{}, err
+			}
+			info.Qualifiers = append(info.Qualifiers, policyQualifierInfo{
+				PolicyQualifierID: oidPolicyQualifierUserNotice,
+				Qualifier:         asn1.RawValue{FullBytes: notice},
+			})
+		}
+		infos = append(infos, info)
+	}
+
+	value, err := asn1.Marshal(infos)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidExtensionCertificatePolicies, Value: value}, nil
+}
+
+// hasExtension reports whether the extensions contain the given OID.
+func hasExtension(exts []pkix.Extension, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range exts {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIA5String reports whether the given string only contains ASCII characters.
+func isIA5String(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package crt_test
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+)
+
+func TestPoliciesAndExtensions(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+
+	oidPolicy := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 1}
+	oidPolicyDV := asn1.ObjectIdentifier{2, 23, 140, 1, 2, 1}
+	oidEKU := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 3, 1}
+	oidExt := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2, 1}
+	value, _ := asn1.Marshal("attested")
+
+	created, _, err := g.Create(NewServerCert(
+		WithDNSNames("attest.example.com"),
+		WithPolicies(
+			Policy{OID: oidPolicy, CPSURI: "https://example.com/cps", UserNotice: "test only"},
+			Policy{OID: oidPolicyDV},
+		),
+		WithUnknownExtKeyUsages(oidEKU),
+		WithExtensions(pkix.Extension{Id: oidExt, Critical: true, Value: value}),
+	))
+	assert.NoError(t, err)
+	parsed, err := parseCertBytes(created)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []asn1.ObjectIdentifier{oidPolicy, oidPolicyDV}, parsed.PolicyIdentifiers)
+	assert.Equal(t, []asn1.ObjectIdentifier{oidEKU}, parsed.UnknownExtKeyUsage)
+
+	var found bool
+	for _, ext := range parsed.Extensions {
+		if !ext.Id.Equal(oidExt) {
+			continue
+		}
+		found = true
+		assert.True(t, ext.Critical)
+		assert.Equal(t, value, ext.Value)
+	}
+	assert.True(t, found)
+	assert.Equal(t, 1, len(parsed.UnhandledCriticalExtensions))
+
+	t.Run("raw extension overrides policies", func(t *testing.T) {
+		raw, _ := asn1.Marshal([]struct{ Policy asn1.ObjectIdentifier }{{oidPolicyDV}})
+		created, _, err := g.Create(NewServerCert(
+			WithPolicies(Policy{OID: oidPolicy, CPSURI: "https://example.com/cps"}),
+			WithExtensions(pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 32}, Value: raw}),
+		))
+		assert.NoError(t, err)
+		parsed, err := parseCertBytes(created)
+		assert.NoError(t, err)
+		assert.Equal(t, []asn1.ObjectIdentifier{oidPolicyDV}, parsed.PolicyIdentifiers)
+	})
+
+	t.Run("should return error: invalid policy OID", func(t *testing.T) {
+		_, _, err := g.Create(NewServerCert(WithPolicies(Policy{OID: asn1.ObjectIdentifier{1}})))
+		assert.Error(t, err)
+	})
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	oidPolicy := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 1}
+
+	_, err := New(WithPolicies(Policy{OID: oidPolicy, UserNotice: "invalid \xff"})).Template()
+	assert.EqualError(t, err, "crt: user notice of policy 1.3.6.1.4.1.99999.1.1 is not a valid UTF8String of up to 200 characters")
+	_, err = New(WithPolicies(Policy{OID: oidPolicy, UserNotice: strings.Repeat("a", 201)})).Template()
+	assert.Error(t, err)
+
+	_, err = New(WithPolicies(Policy{OID: oidPolicy, CPSURI: "https://example.com/cps/é"})).Template()
+	assert.EqualError(t, err, `crt: CPS URI "https://example.com/cps/é" of policy 1.3.6.1.4.1.99999.1.1 is not a valid IA5String`)
+
+	g := createEcdsaGenWithCA(t)
+	_, _, err = g.Create(NewServerCert(WithPolicies(Policy{OID: oidPolicy, UserNotice: "invalid \xff"})))
+	assert.Error(t, err)
+}
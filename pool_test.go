@@ -0,0 +1,127 @@
+package crt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func waitAvailable(t *testing.T, pool *key.Pool, n int) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return pool.Stats().Available >= n
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPool(t *testing.T) {
+	pool, err := key.NewPool(context.Background(), key.NewEcdsaKey(nil), key.WithPoolDepth(2), key.WithPoolWorkers(1))
+	assert.NoError(t, err)
+	defer func() { _ = pool.Close() }()
+	waitAvailable(t, pool, 2)
+
+	g := generator.New(generator.WithKeyGenerator(pool))
+	_, _, err = g.CreateWithOptions(NewCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	_, pkey, err := g.Create(NewServerCert())
+	assert.NoError(t, err)
+	_, err = key.ParsePrivateKey(pkey, nil)
+	assert.NoError(t, err)
+
+	stats := pool.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.GreaterOrEqual(t, stats.Generated, uint64(2))
+
+	t.Run("closed", func(t *testing.T) {
+		assert.NoError(t, pool.Close())
+		for i := 0; i < 5; i++ {
+			_, err := pool.Gen()
+			assert.NoError(t, err)
+		}
+		assert.Greater(t, pool.Stats().Misses, uint64(0))
+	})
+}
+
+func TestPoolContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool, err := key.NewPool(ctx, key.NewEcdsaKey(nil), key.WithPoolDepth(1))
+	assert.NoError(t, err)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		_ = pool.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the workers are not stopped")
+	}
+}
+
+func TestPoolCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+
+	pool, err := key.NewPool(context.Background(), key.NewEcdsaKey(nil), key.WithPoolDepth(2), key.WithPoolWorkers(1), key.WithPoolCache(dir, 3))
+	assert.NoError(t, err)
+	waitAvailable(t, pool, 2)
+	first, err := pool.Gen()
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Close())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(entries), 3)
+	assert.GreaterOrEqual(t, len(entries), 2)
+
+	t.Run("load cache", func(t *testing.T) {
+		pool, err := key.NewPool(context.Background(), key.NewEcdsaKey(nil), key.WithPoolWorkers(1), key.WithPoolCache(dir, 3))
+		assert.NoError(t, err)
+		defer func() { _ = pool.Close() }()
+		assert.Equal(t, uint64(len(entries)), pool.Stats().Cached)
+
+		found := false
+		for i := 0; i < len(entries); i++ {
+			k, err := pool.Gen()
+			assert.NoError(t, err)
+			if first.Public().(*ecdsa.PublicKey).Equal(k.Public()) {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+	t.Run("should skip the keys of other types", func(t *testing.T) {
+		pool, err := key.NewPool(context.Background(), key.NewEcdsaKey(elliptic.P384()), key.WithPoolWorkers(1), key.WithPoolCache(dir, 3))
+		assert.NoError(t, err)
+		defer func() { _ = pool.Close() }()
+		assert.Equal(t, uint64(0), pool.Stats().Cached)
+
+		k, err := pool.Gen()
+		assert.NoError(t, err)
+		assert.Equal(t, elliptic.P384(), k.(*ecdsa.PrivateKey).Curve)
+	})
+	t.Run("should check the cache after the first key of an unknown key type", func(t *testing.T) {
+		pool, err := key.NewPool(context.Background(), wrappedKey{key.NewEcdsaKey(nil)}, key.WithPoolDepth(1), key.WithPoolWorkers(1), key.WithPoolCache(dir, 3))
+		assert.NoError(t, err)
+		defer func() { _ = pool.Close() }()
+
+		assert.Eventually(t, func() bool {
+			return pool.Stats().Cached == uint64(len(entries))
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// wrappedKey is a Generator that is not one of the built-in Generators.
+type wrappedKey struct {
+	*key.EcdsaKey
+}
@@ -0,0 +1,3 @@
+// Package profile defines declarative certificate profiles, which can be loaded
+// from JSON or YAML files and converted to crt.Certificate and key.Generator instances.
+package profile
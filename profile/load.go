@@ -0,0 +1,146 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles is a set of named profiles.
+type Profiles map[string]*Profile
+
+// Builtin returns the built-in profiles "ca", "intermediate", "server",
+// "client" and "peer", they mirror crt.NewCACert, crt.NewIntermediateCACert,
+// crt.NewServerCert, crt.NewClientCert and crt.NewPeerCert.
+func Builtin() Profiles {
+	return Profiles{
+		TypeCA:           {Type: TypeCA},
+		TypeIntermediate: {Type: TypeIntermediate},
+		TypeServer:       {Type: TypeServer},
+		TypeClient:       {Type: TypeClient},
+		TypePeer:         {Type: TypePeer},
+	}
+}
+
+// Get returns the profile with the given name.
+func (ps Profiles) Get(name string) (*Profile, error) {
+	p, ok := ps[name]
+	if !ok {
+		return nil, fmt.Errorf("profile: profile %q not found", name)
+	}
+	return p, nil
+}
+
+// Names returns the sorted names of the profiles.
+func (ps Profiles) Names() []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load reads the profiles from the given JSON or YAML file, the format is
+// detected by the file extension: ".json", ".yaml" or ".yml".
+func Load(fpath string) (Profiles, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(fpath)) {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	}
+	return nil, fmt.Errorf("profile: unsupported file extension %q", filepath.Ext(fpath))
+}
+
+// ParseJSON parses the profiles from JSON data, the profiles are defined
+// in the "profiles" object, keyed by name:
+//
+//	{"profiles": {"web": {"type": "server", "dns_names": ["example.com"]}}}
+//
+// The returned Profiles contain the built-in profiles, a profile with the name
+// of a built-in profile overrides the fields of the built-in profile.
+// Unknown fields are rejected.
+func ParseJSON(data []byte) (Profiles, error) {
+	var doc struct {
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := decodeJSON(data, &doc); err != nil {
+		return nil, err
+	}
+
+	ps := Builtin()
+	for name, raw := range doc.Profiles {
+		p := profileOf(ps, name)
+		if err := decodeJSON(raw, p); err != nil {
+			return nil, fmt.Errorf("profile: invalid profile %q: %w", name, err)
+		}
+		ps[name] = p
+	}
+	return ps, nil
+}
+
+// ParseYAML parses the profiles from YAML data, the format is the same as ParseJSON:
+//
+//	profiles:
+//	  web:
+//	    type: server
+//	    dns_names: [example.com]
+func ParseYAML(data []byte) (Profiles, error) {
+	var doc struct {
+		Profiles map[string]yaml.Node `yaml:"profiles"`
+	}
+	if err := decodeYAML(data, &doc); err != nil {
+		return nil, err
+	}
+
+	ps := Builtin()
+	for name, node := range doc.Profiles {
+		raw, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, err
+		}
+		p := profileOf(ps, name)
+		if err = decodeYAML(raw, p); err != nil {
+			return nil, fmt.Errorf("profile: invalid profile %q: %w", name, err)
+		}
+		ps[name] = p
+	}
+	return ps, nil
+}
+
+// profileOf returns a copy of the existing profile with the given name,
+// or a new empty profile.
+func profileOf(ps Profiles, name string) *Profile {
+	if p, ok := ps[name]; ok {
+		return p.clone()
+	}
+	return &Profile{}
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func decodeYAML(data []byte, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
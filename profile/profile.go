@@ -0,0 +1,305 @@
+package profile
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+// The types of the profile, a profile with an empty type is a generic certificate.
+const (
+	TypeCA           = "ca"
+	TypeIntermediate = "intermediate"
+	TypeServer       = "server"
+	TypeClient       = "client"
+	TypePeer         = "peer"
+)
+
+// The algorithms of the private key.
+const (
+	AlgorithmRSA     = "rsa"
+	AlgorithmECDSA   = "ecdsa"
+	AlgorithmEd25519 = "ed25519"
+)
+
+// Profile describes a certificate.
+type Profile struct {
+	// Type is one of "ca", "intermediate", "server", "client" and "peer",
+	// it selects the constructor of the crt.Certificate, e.g. "server" uses
+	// crt.NewServerCert.
+	Type    string  `json:"type,omitempty" yaml:"type,omitempty"`
+	Subject Subject `json:"subject,omitempty" yaml:"subject,omitempty"`
+	// Validity is a duration string, e.g. "720h", the "d" unit is also
+	// supported, e.g. "365d".
+	Validity     string   `json:"validity,omitempty" yaml:"validity,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty" yaml:"dns_names,omitempty"`
+	IPs          []string `json:"ips,omitempty" yaml:"ips,omitempty"`
+	URIs         []string `json:"uris,omitempty" yaml:"uris,omitempty"`
+	Emails       []string `json:"emails,omitempty" yaml:"emails,omitempty"`
+	KeyUsages    []string `json:"key_usages,omitempty" yaml:"key_usages,omitempty"`
+	ExtKeyUsages []string `json:"ext_key_usages,omitempty" yaml:"ext_key_usages,omitempty"`
+	// MaxPathLen is ignored if the profile is not CA type, see crt.WithMaxPathLen.
+	MaxPathLen *int    `json:"max_path_len,omitempty" yaml:"max_path_len,omitempty"`
+	Key        Key     `json:"key,omitempty" yaml:"key,omitempty"`
+	Issuer     *Issuer `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+}
+
+// Subject describes the subject of a certificate.
+// DN is a distinguished name string, e.g. "CN=foo,O=bar,C=US", see crt.ParseDN.
+// The other fields take precedence over the attributes of the DN.
+type Subject struct {
+	DN                  string   `json:"dn,omitempty" yaml:"dn,omitempty"`
+	CommonName          string   `json:"common_name,omitempty" yaml:"common_name,omitempty"`
+	Organizations       []string `json:"organizations,omitempty" yaml:"organizations,omitempty"`
+	OrganizationalUnits []string `json:"organizational_units,omitempty" yaml:"organizational_units,omitempty"`
+	Countries           []string `json:"countries,omitempty" yaml:"countries,omitempty"`
+	Provinces           []string `json:"provinces,omitempty" yaml:"provinces,omitempty"`
+	Localities          []string `json:"localities,omitempty" yaml:"localities,omitempty"`
+}
+
+// Key describes the private key of a certificate.
+// Algorithm is one of "rsa", "ecdsa" and "ed25519", default is "rsa".
+// Size is the size of the RSA key, default is key.RecommendedKeyLength.
+// Curve is the curve of the ECDSA key, one of "P-224", "P-256", "P-384"
+// and "P-521", default is "P-256".
+type Key struct {
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	Size      int    `json:"size,omitempty" yaml:"size,omitempty"`
+	Curve     string `json:"curve,omitempty" yaml:"curve,omitempty"`
+}
+
+// Issuer describes the CA pair used to issue the certificate.
+type Issuer struct {
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// Certificate returns a new crt.Certificate described by the profile.
+// The key usages of the profile are merged with the default key usages of
+// the type. The ext key usages of the type are appended to the ext key usages
+// of the profile, each ext key usage is only included once.
+func (p *Profile) Certificate() (*crt.Certificate, error) {
+	opts, err := p.options()
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Type {
+	case TypeCA:
+		return crt.NewCACert(opts...), nil
+	case TypeIntermediate:
+		return crt.NewIntermediateCACert(opts...), nil
+	case TypeServer:
+		return crt.NewServerCert(opts...), nil
+	case TypeClient:
+		return crt.NewClientCert(opts...), nil
+	case TypePeer:
+		return crt.NewPeerCert(opts...), nil
+	case "":
+		return crt.New(opts...), nil
+	}
+	return nil, fmt.Errorf("profile: unknown type %q", p.Type)
+}
+
+// KeyGenerator returns a new key.Generator described by the profile.
+func (p *Profile) KeyGenerator() (key.Generator, error) {
+	switch strings.ToLower(p.Key.Algorithm) {
+	case "", AlgorithmRSA:
+		if p.Key.Size < 0 {
+			return nil, fmt.Errorf("profile: invalid RSA key size %d", p.Key.Size)
+		}
+		if p.Key.Size == 0 {
+			return key.NewRsaKey(key.RecommendedKeyLength), nil
+		}
+		return key.NewRsaKey(p.Key.Size), nil
+	case AlgorithmECDSA:
+		curve, err := parseCurve(p.Key.Curve)
+		if err != nil {
+			return nil, err
+		}
+		return key.NewEcdsaKey(curve), nil
+	case AlgorithmEd25519:
+		return key.NewEd25519Key(), nil
+	}
+	return nil, fmt.Errorf("profile: unknown key algorithm %q", p.Key.Algorithm)
+}
+
+// Generator returns a new generator.Generator with the key.Generator of the profile.
+// If the Issuer of the profile is set, the CA pair is loaded from the files.
+func (p *Profile) Generator(opts ...generator.Option) (*generator.Generator, error) {
+	keyG, err := p.KeyGenerator()
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]generator.Option{generator.WithKeyGenerator(keyG)}, opts...)
+	if p.Issuer == nil {
+		return generator.New(opts...), nil
+	}
+
+	var password []byte
+	if len(p.Issuer.Password) > 0 {
+		password = []byte(p.Issuer.Password)
+	}
+	return generator.NewFromCAFiles(p.Issuer.CertFile, p.Issuer.KeyFile, password, opts...)
+}
+
+// options returns the crt.Option values of the profile.
+func (p *Profile) options() ([]crt.Option, error) {
+	var opts []crt.Option
+
+	if len(p.Subject.DN) > 0 {
+		name, err := crt.ParseDN(p.Subject.DN)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, crt.WithSubject(name))
+	}
+	if len(p.Subject.CommonName) > 0 {
+		opts = append(opts, crt.WithCN(p.Subject.CommonName))
+	}
+	if len(p.Subject.Organizations) > 0 {
+		opts = append(opts, crt.WithOrganizations(p.Subject.Organizations...))
+	}
+	if len(p.Subject.OrganizationalUnits) > 0 {
+		opts = append(opts, crt.WithOrganizationalUnits(p.Subject.OrganizationalUnits...))
+	}
+	if len(p.Subject.Countries) > 0 {
+		opts = append(opts, crt.WithCountries(p.Subject.Countries...))
+	}
+	if len(p.Subject.Provinces) > 0 {
+		opts = append(opts, crt.WithProvinces(p.Subject.Provinces...))
+	}
+	if len(p.Subject.Localities) > 0 {
+		opts = append(opts, crt.WithLocalities(p.Subject.Localities...))
+	}
+
+	if len(p.Validity) > 0 {
+		validity, err := ParseValidity(p.Validity)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, crt.WithValidity(validity))
+	}
+
+	if len(p.DNSNames) > 0 {
+		opts = append(opts, crt.WithDNSNames(p.DNSNames...))
+	}
+	if len(p.IPs) > 0 {
+		ips := make([]net.IP, 0, len(p.IPs))
+		for _, v := range p.IPs {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("profile: invalid IP address %q", v)
+			}
+			ips = append(ips, ip)
+		}
+		opts = append(opts, crt.WithIPs(ips...))
+	}
+	if len(p.URIs) > 0 {
+		uris := make([]*url.URL, 0, len(p.URIs))
+		for _, v := range p.URIs {
+			u, err := url.Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("profile: invalid URI %q: %w", v, err)
+			}
+			uris = append(uris, u)
+		}
+		opts = append(opts, crt.WithURIs(uris...))
+	}
+	if len(p.Emails) > 0 {
+		opts = append(opts, crt.WithEmailAddresses(p.Emails...))
+	}
+
+	if len(p.KeyUsages) > 0 {
+		usage, err := ParseKeyUsages(p.KeyUsages...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, crt.WithKeyUsage(usage))
+	}
+	if len(p.ExtKeyUsages) > 0 {
+		usages, err := ParseExtKeyUsages(p.ExtKeyUsages...)
+		if err != nil {
+			return nil, err
+		}
+		// the ext key usages of the type are appended by the constructor of the type
+		opts = append(opts, crt.WithExtKeyUsages(uniqueExtKeyUsages(usages, _typeExtKeyUsages[p.Type])...))
+	}
+
+	if p.MaxPathLen != nil {
+		opts = append(opts, crt.WithMaxPathLen(*p.MaxPathLen))
+	}
+	return opts, nil
+}
+
+// clone returns a deep copy of the profile.
+func (p *Profile) clone() *Profile {
+	cp := *p
+	cp.Subject.Organizations = cloneStrings(p.Subject.Organizations)
+	cp.Subject.OrganizationalUnits = cloneStrings(p.Subject.OrganizationalUnits)
+	cp.Subject.Countries = cloneStrings(p.Subject.Countries)
+	cp.Subject.Provinces = cloneStrings(p.Subject.Provinces)
+	cp.Subject.Localities = cloneStrings(p.Subject.Localities)
+	cp.DNSNames = cloneStrings(p.DNSNames)
+	cp.IPs = cloneStrings(p.IPs)
+	cp.URIs = cloneStrings(p.URIs)
+	cp.Emails = cloneStrings(p.Emails)
+	cp.KeyUsages = cloneStrings(p.KeyUsages)
+	cp.ExtKeyUsages = cloneStrings(p.ExtKeyUsages)
+	if p.MaxPathLen != nil {
+		n := *p.MaxPathLen
+		cp.MaxPathLen = &n
+	}
+	if p.Issuer != nil {
+		issuer := *p.Issuer
+		cp.Issuer = &issuer
+	}
+	return &cp
+}
+
+// ParseValidity parses a validity duration string, in addition to the units
+// of time.ParseDuration, the "d" unit is supported, e.g. "365d".
+func ParseValidity(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("profile: invalid validity %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("profile: invalid validity %q", s)
+	}
+	return d, nil
+}
+
+func parseCurve(name string) (elliptic.Curve, error) {
+	switch normalize(name) {
+	case "", "p256", "prime256v1", "secp256r1":
+		return elliptic.P256(), nil
+	case "p224", "secp224r1":
+		return elliptic.P224(), nil
+	case "p384", "secp384r1":
+		return elliptic.P384(), nil
+	case "p521", "secp521r1":
+		return elliptic.P521(), nil
+	}
+	return nil, fmt.Errorf("profile: unknown curve %q", name)
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
@@ -0,0 +1,242 @@
+package profile
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+const testYAML = `
+profiles:
+  server:
+    validity: 30d
+    key:
+      algorithm: ecdsa
+  web:
+    type: server
+    subject:
+      dn: "CN=ignored,O=Example,C=US"
+      common_name: web.example.com
+    dns_names: [web.example.com, www.example.com]
+    ips: [127.0.0.1]
+    uris: ["spiffe://example.org/web"]
+    emails: [admin@example.com]
+    key_usages: [digital_signature, key_agreement]
+    ext_key_usages: [client-auth]
+    validity: 720h
+    key:
+      algorithm: ecdsa
+      curve: P-384
+`
+
+const testJSON = `{
+  "profiles": {
+    "client": {"validity": "24h", "key": {"algorithm": "ed25519"}},
+    "root": {"type": "ca", "subject": {"common_name": "Test Root"}, "max_path_len": 1, "key": {"algorithm": "ecdsa"}}
+  }
+}`
+
+func TestParseYAML(t *testing.T) {
+	ps, err := ParseYAML([]byte(testYAML))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ca", "client", "intermediate", "peer", "server", "web"}, ps.Names())
+
+	t.Run("override built-in profile", func(t *testing.T) {
+		p, err := ps.Get("server")
+		assert.NoError(t, err)
+		assert.Equal(t, TypeServer, p.Type)
+		assert.Equal(t, "30d", p.Validity)
+
+		c, err := p.Certificate()
+		assert.NoError(t, err)
+		assert.True(t, c.IsServerCert())
+
+		g, err := p.KeyGenerator()
+		assert.NoError(t, err)
+		assert.IsType(t, &key.EcdsaKey{}, g)
+	})
+
+	t.Run("custom profile", func(t *testing.T) {
+		p, err := ps.Get("web")
+		assert.NoError(t, err)
+		tmpl, err := p.Certificate()
+		assert.NoError(t, err)
+
+		cert, err := tmpl.Template()
+		assert.NoError(t, err)
+		assert.Equal(t, "web.example.com", cert.Subject.CommonName)
+		assert.Equal(t, []string{"Example"}, cert.Subject.Organization)
+		assert.Equal(t, []string{"US"}, cert.Subject.Country)
+		assert.Equal(t, []string{"web.example.com", "www.example.com"}, cert.DNSNames)
+		assert.Equal(t, "127.0.0.1", cert.IPAddresses[0].String())
+		assert.Equal(t, "spiffe://example.org/web", cert.URIs[0].String())
+		assert.Equal(t, []string{"admin@example.com"}, cert.EmailAddresses)
+		assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment|x509.KeyUsageKeyAgreement, cert.KeyUsage)
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}, cert.ExtKeyUsage)
+		assert.Equal(t, 720*time.Hour, cert.NotAfter.Sub(cert.NotBefore).Round(time.Second))
+
+		kg, err := p.KeyGenerator()
+		assert.NoError(t, err)
+		signer, err := kg.Gen()
+		assert.NoError(t, err)
+		assert.Equal(t, elliptic.P384(), signer.(*ecdsa.PrivateKey).Curve)
+	})
+
+	t.Run("built-in profiles are not modified", func(t *testing.T) {
+		assert.Empty(t, Builtin()["server"].Validity)
+	})
+}
+
+func TestExtKeyUsages(t *testing.T) {
+	tests := []struct {
+		title    string
+		p        *Profile
+		expected []x509.ExtKeyUsage
+	}{
+		{"server", &Profile{Type: TypeServer, ExtKeyUsages: []string{"server_auth", "code_signing", "serverAuth"}},
+			[]x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning, x509.ExtKeyUsageServerAuth}},
+		{"peer", &Profile{Type: TypePeer, ExtKeyUsages: []string{"client_auth", "server_auth"}},
+			[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}},
+		{"no type", &Profile{ExtKeyUsages: []string{"server_auth", "server_auth"}},
+			[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+	}
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			c, err := v.p.Certificate()
+			assert.NoError(t, err)
+			cert, err := c.Template()
+			assert.NoError(t, err)
+			assert.Equal(t, v.expected, cert.ExtKeyUsage)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	ps, err := ParseJSON([]byte(testJSON))
+	assert.NoError(t, err)
+
+	root, err := ps.Get("root")
+	assert.NoError(t, err)
+	g, err := root.Generator()
+	assert.NoError(t, err)
+	rootCert, err := root.Certificate()
+	assert.NoError(t, err)
+	_, _, err = g.CreateWithOptions(rootCert, generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	ca, _ := g.CA()
+	assert.Equal(t, "Test Root", ca.Subject.CommonName)
+	assert.Equal(t, 1, ca.MaxPathLen)
+
+	client, err := ps.Get("client")
+	assert.NoError(t, err)
+	kg, err := client.KeyGenerator()
+	assert.NoError(t, err)
+	signer, err := kg.Gen()
+	assert.NoError(t, err)
+	assert.IsType(t, ed25519.PrivateKey{}, signer)
+	c, err := client.Certificate()
+	assert.NoError(t, err)
+	assert.True(t, c.IsClientCert())
+	cert, err := c.Template()
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, cert.NotAfter.Sub(cert.NotBefore).Round(time.Second))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "profiles.yml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte(testYAML), 0o600))
+	ps, err := Load(yamlFile)
+	assert.NoError(t, err)
+	assert.Contains(t, ps, "web")
+
+	jsonFile := filepath.Join(dir, "profiles.json")
+	assert.NoError(t, os.WriteFile(jsonFile, []byte(testJSON), 0o600))
+	ps, err = Load(jsonFile)
+	assert.NoError(t, err)
+	assert.Contains(t, ps, "root")
+
+	t.Run("load issuer", func(t *testing.T) {
+		g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+		w, err := generator.NewFileWriterFromPaths(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+		assert.NoError(t, err)
+		assert.NoError(t, g.CreateAndWrite(w, crt.NewCACert()))
+
+		p := &Profile{
+			Type: TypeServer,
+			Key:  Key{Algorithm: AlgorithmECDSA},
+			Issuer: &Issuer{
+				CertFile: filepath.Join(dir, "ca.crt"),
+				KeyFile:  filepath.Join(dir, "ca.key"),
+			},
+		}
+		issuer, err := p.Generator()
+		assert.NoError(t, err)
+		ca, _ := issuer.CA()
+		assert.Equal(t, "CRT GENERATOR CA", ca.Subject.CommonName)
+	})
+
+	t.Run("should return error: unsupported file extension", func(t *testing.T) {
+		fpath := filepath.Join(dir, "profiles.toml")
+		assert.NoError(t, os.WriteFile(fpath, nil, 0o600))
+		_, err := Load(fpath)
+		assert.Equal(t, "profile: unsupported file extension \".toml\"", err.Error())
+	})
+}
+
+func TestProfileErrors(t *testing.T) {
+	tests := []struct {
+		title    string
+		profile  *Profile
+		expected string
+	}{
+		{"unknown type", &Profile{Type: "leaf"}, "profile: unknown type \"leaf\""},
+		{"invalid validity", &Profile{Validity: "one year"}, "profile: invalid validity \"one year\""},
+		{"invalid IP", &Profile{IPs: []string{"localhost"}}, "profile: invalid IP address \"localhost\""},
+		{"unknown key usage", &Profile{KeyUsages: []string{"sign"}}, "profile: unknown key usage \"sign\""},
+		{"unknown ext key usage", &Profile{ExtKeyUsages: []string{"web"}}, "profile: unknown ext key usage \"web\""},
+	}
+	for _, v := range tests {
+		t.Run("should return error: "+v.title, func(t *testing.T) {
+			_, err := v.profile.Certificate()
+			assert.Equal(t, v.expected, err.Error())
+		})
+	}
+
+	t.Run("should return error: unknown key algorithm", func(t *testing.T) {
+		_, err := (&Profile{Key: Key{Algorithm: "dsa"}}).KeyGenerator()
+		assert.Equal(t, "profile: unknown key algorithm \"dsa\"", err.Error())
+		_, err = (&Profile{Key: Key{Algorithm: "ecdsa", Curve: "P-192"}}).KeyGenerator()
+		assert.Equal(t, "profile: unknown curve \"P-192\"", err.Error())
+	})
+
+	t.Run("should return error: unknown fields", func(t *testing.T) {
+		_, err := ParseYAML([]byte("profiles:\n  web:\n    dns: [example.com]\n"))
+		assert.Error(t, err)
+		_, err = ParseJSON([]byte(`{"profiles": {"web": {"dns": ["example.com"]}}}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseValidity(t *testing.T) {
+	d, err := ParseValidity("365d")
+	assert.NoError(t, err)
+	assert.Equal(t, 365*24*time.Hour, d)
+	d, err = ParseValidity("90m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+	_, err = ParseValidity("-1d")
+	assert.Error(t, err)
+}
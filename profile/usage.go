@@ -0,0 +1,94 @@
+package profile
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// _keyUsages maps the normalized names to x509.KeyUsage values.
+var _keyUsages = map[string]x509.KeyUsage{
+	"digitalsignature":  x509.KeyUsageDigitalSignature,
+	"contentcommitment": x509.KeyUsageContentCommitment,
+	"nonrepudiation":    x509.KeyUsageContentCommitment,
+	"keyencipherment":   x509.KeyUsageKeyEncipherment,
+	"dataencipherment":  x509.KeyUsageDataEncipherment,
+	"keyagreement":      x509.KeyUsageKeyAgreement,
+	"certsign":          x509.KeyUsageCertSign,
+	"keycertsign":       x509.KeyUsageCertSign,
+	"crlsign":           x509.KeyUsageCRLSign,
+	"encipheronly":      x509.KeyUsageEncipherOnly,
+	"decipheronly":      x509.KeyUsageDecipherOnly,
+}
+
+// _extKeyUsages maps the normalized names to x509.ExtKeyUsage values.
+var _extKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":             x509.ExtKeyUsageAny,
+	"serverauth":      x509.ExtKeyUsageServerAuth,
+	"clientauth":      x509.ExtKeyUsageClientAuth,
+	"codesigning":     x509.ExtKeyUsageCodeSigning,
+	"emailprotection": x509.ExtKeyUsageEmailProtection,
+	"ipsecendsystem":  x509.ExtKeyUsageIPSECEndSystem,
+	"ipsectunnel":     x509.ExtKeyUsageIPSECTunnel,
+	"ipsecuser":       x509.ExtKeyUsageIPSECUser,
+	"timestamping":    x509.ExtKeyUsageTimeStamping,
+	"ocspsigning":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// _typeExtKeyUsages maps the types to the ext key usages added by the type.
+var _typeExtKeyUsages = map[string][]x509.ExtKeyUsage{
+	TypeServer: {x509.ExtKeyUsageServerAuth},
+	TypeClient: {x509.ExtKeyUsageClientAuth},
+	TypePeer:   {x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+}
+
+// ParseKeyUsages parses the names of key usages and returns the merged x509.KeyUsage.
+// The names are case-insensitive, and "-", "_" and spaces are ignored, so
+// "digital_signature", "digital-signature" and "digitalSignature" are the same.
+func ParseKeyUsages(names ...string) (x509.KeyUsage, error) {
+	var usage x509.KeyUsage
+	for _, name := range names {
+		v, ok := _keyUsages[normalize(name)]
+		if !ok {
+			return 0, fmt.Errorf("profile: unknown key usage %q", name)
+		}
+		usage |= v
+	}
+	return usage, nil
+}
+
+// ParseExtKeyUsages parses the names of extended key usages, the names
+// follow the same rules as ParseKeyUsages, e.g. "server_auth".
+func ParseExtKeyUsages(names ...string) ([]x509.ExtKeyUsage, error) {
+	usages := make([]x509.ExtKeyUsage, 0, len(names))
+	for _, name := range names {
+		v, ok := _extKeyUsages[normalize(name)]
+		if !ok {
+			return nil, fmt.Errorf("profile: unknown ext key usage %q", name)
+		}
+		usages = append(usages, v)
+	}
+	return usages, nil
+}
+
+// uniqueExtKeyUsages returns the given usages without the duplicates and the
+// excluded usages, the order is preserved.
+func uniqueExtKeyUsages(usages, excluded []x509.ExtKeyUsage) []x509.ExtKeyUsage {
+	seen := make(map[x509.ExtKeyUsage]struct{}, len(usages)+len(excluded))
+	for _, v := range excluded {
+		seen[v] = struct{}{}
+	}
+	unique := make([]x509.ExtKeyUsage, 0, len(usages))
+	for _, v := range usages {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+func normalize(name string) string {
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(name))
+}
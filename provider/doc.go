@@ -0,0 +1,3 @@
+// Package provider defines a hot-reloading certificate provider, it holds a
+// tls.Certificate issued by a generator.Generator and re-issues it before expiry.
+package provider
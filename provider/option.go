@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// Option defines optional parameters for initializing the provider
+// structure.
+type Option interface {
+	apply(p *Provider)
+}
+
+// optionFunc wraps a func, so it satisfies the Option interface.
+type optionFunc func(*Provider)
+
+func (fn optionFunc) apply(p *Provider) {
+	fn(p)
+}
+
+// WithRenewalFraction is used to set the renewal window as a fraction of the
+// validity of the certificate, e.g. 0.25 means that the certificate is
+// re-issued when less than a quarter of its validity remains.
+// The fraction must be in (0, 1), otherwise it is ignored.
+func WithRenewalFraction(fraction float64) Option {
+	return optionFunc(func(p *Provider) {
+		if fraction > 0 && fraction < 1 {
+			p.fraction = fraction
+		}
+	})
+}
+
+// WithClock is used to set the Clock of the Provider, it is used to decide
+// when the certificate is renewed, so the rotation can be tested without waiting.
+// Default is the Clock of the Generator, see generator.Generator.Now.
+func WithClock(clock Clock) Option {
+	return optionFunc(func(p *Provider) {
+		if clock != nil {
+			p.clock = clock
+		}
+	})
+}
+
+// WithCheckInterval is used to set the interval of the renewal checks of Provider.Run,
+// and the delay of the retries after a failed renewal.
+func WithCheckInterval(interval time.Duration) Option {
+	return optionFunc(func(p *Provider) {
+		if interval > 0 {
+			p.interval = interval
+		}
+	})
+}
+
+// WithOnRenew is used to set the function called after the certificate is renewed.
+// The function is called with the lock of the Provider held, so it must not call
+// the methods of the Provider.
+func WithOnRenew(fn func(cert *tls.Certificate)) Option {
+	return optionFunc(func(p *Provider) {
+		p.onRenew = fn
+	})
+}
+
+// WithOnError is used to set the function called when the renewal fails.
+// The function is called without the lock of the Provider held. Certificate
+// reports the failures while the current certificate is still valid, and
+// returns the error once it has expired, Run reports both.
+func WithOnError(fn func(err error)) Option {
+	return optionFunc(func(p *Provider) {
+		p.onError = fn
+	})
+}
@@ -0,0 +1,213 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+)
+
+const (
+	_defaultRenewalFraction = 1.0 / 3
+	_defaultCheckInterval   = time.Minute
+)
+
+// Clock provides the current time.
+type Clock = crt.Clock
+
+// generatorClock is the Clock of the Generator.
+type generatorClock struct {
+	g *generator.Generator
+}
+
+func (c generatorClock) Now() time.Time { return c.g.Now() }
+
+// Provider holds the current tls.Certificate issued by a generator.Generator,
+// and re-issues it when the renewal window is reached. The renewal is checked
+// lazily on each access, and periodically by Run.
+// The renewal is due when the given fraction of the validity of the certificate
+// remains, i.e. at NotAfter minus fraction of (NotAfter - NotBefore), so the
+// Clock of the Provider should agree with the Clock of the Generator, by
+// default, the Clock of the Generator is used. A failed renewal is retried
+// after the check interval.
+// A Provider is safe for concurrent use, the certificate is issued without
+// the lock held, so the current certificate is served during the renewal.
+type Provider struct {
+	g        *generator.Generator
+	tmpl     *crt.Certificate
+	fraction float64
+	clock    Clock
+	interval time.Duration
+	onRenew  func(cert *tls.Certificate)
+	onError  func(err error)
+
+	renewMu   sync.Mutex // serializes the renewals
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	renewAt   time.Time
+	retryAt   time.Time
+	expiresAt time.Time
+}
+
+// New creates a new Provider, and issues the first certificate from the given
+// Generator based on the given template.
+func New(g *generator.Generator, c *crt.Certificate, opts ...Option) (*Provider, error) {
+	p := &Provider{
+		g:        g,
+		tmpl:     c,
+		fraction: _defaultRenewalFraction,
+		clock:    generatorClock{g: g},
+		interval: _defaultCheckInterval,
+	}
+	p.withOptions(opts...)
+
+	if err := p.Renew(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Certificate returns the current certificate, the certificate is renewed
+// first if the renewal is due. If the renewal fails, the current certificate
+// is returned until it expires.
+func (p *Provider) Certificate() (*tls.Certificate, error) {
+	now := p.clock.Now()
+	cert, due := p.current(now)
+	if !due {
+		return cert, nil
+	}
+
+	if !p.renewMu.TryLock() {
+		// the certificate is being renewed by another goroutine,
+		// serve the current certificate unless it has expired
+		if now.Before(p.ExpiresAt()) {
+			return cert, nil
+		}
+		p.renewMu.Lock()
+	}
+	cert, due = p.current(now)
+	if !due { // renewed by another goroutine
+		p.renewMu.Unlock()
+		return cert, nil
+	}
+	err := p.renew(now)
+	p.mu.Lock()
+	if err != nil {
+		p.retryAt = now.Add(p.interval)
+	}
+	cert, valid := p.cert, now.Before(p.expiresAt)
+	p.mu.Unlock()
+	p.renewMu.Unlock()
+
+	if err == nil {
+		return cert, nil
+	}
+	if valid {
+		if p.onError != nil {
+			p.onError(err)
+		}
+		return cert, nil
+	}
+	return nil, fmt.Errorf("provider: certificate has expired and cannot be renewed: %w", err)
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate.
+func (p *Provider) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return p.Certificate()
+}
+
+// GetClientCertificate can be used as tls.Config.GetClientCertificate.
+func (p *Provider) GetClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return p.Certificate()
+}
+
+// Renew re-issues the certificate immediately.
+func (p *Provider) Renew() error {
+	p.renewMu.Lock()
+	defer p.renewMu.Unlock()
+
+	return p.renew(p.clock.Now())
+}
+
+// RenewAt returns the time when the renewal of the current certificate is due.
+func (p *Provider) RenewAt() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.renewAt
+}
+
+// ExpiresAt returns the NotAfter of the current certificate.
+func (p *Provider) ExpiresAt() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.expiresAt
+}
+
+// Run checks the renewal periodically until the context is done,
+// it returns the error of the context. The renewal failures are reported
+// to the function of WithOnError, including the ones after the certificate
+// has expired.
+func (p *Provider) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := p.Certificate(); err != nil && p.onError != nil {
+				p.onError(err)
+			}
+		}
+	}
+}
+
+// current returns the current certificate, and whether the renewal is due.
+func (p *Provider) current(now time.Time) (*tls.Certificate, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.cert, !now.Before(p.renewAt) && !now.Before(p.retryAt)
+}
+
+// renew issues a new certificate, p.renewMu must be held.
+// The new certificate is rejected if its renewal is already due at the given
+// time, e.g. the NotAfter of the template is absolute, otherwise it would be
+// re-issued on every access.
+func (p *Provider) renew(now time.Time) error {
+	cert, err := p.g.TLSCertificate(p.tmpl)
+	if err != nil {
+		return err
+	}
+	validity := cert.Leaf.NotAfter.Sub(cert.Leaf.NotBefore)
+	renewAt := cert.Leaf.NotAfter.Add(-time.Duration(float64(validity) * p.fraction))
+	if !renewAt.After(now) {
+		return fmt.Errorf("provider: renewal of the new certificate is due at %s, not after the current time %s",
+			renewAt.Format(time.RFC3339), now.Format(time.RFC3339))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cert = &cert
+	p.expiresAt = cert.Leaf.NotAfter
+	p.renewAt = renewAt
+	p.retryAt = time.Time{}
+	if p.onRenew != nil {
+		p.onRenew(p.cert)
+	}
+	return nil
+}
+
+// withOptions set options for the Provider.
+func (p *Provider) withOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+}
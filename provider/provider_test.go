@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// blockingKey blocks Gen after block is called, until release is called.
+type blockingKey struct {
+	*key.EcdsaKey
+	started  chan struct{}
+	released chan struct{}
+}
+
+func (k *blockingKey) block() {
+	k.started = make(chan struct{})
+	k.released = make(chan struct{})
+}
+
+func (k *blockingKey) release() {
+	close(k.released)
+}
+
+func (k *blockingKey) Gen() (crypto.Signer, error) {
+	if k.started != nil {
+		close(k.started)
+		<-k.released
+	}
+	return k.EcdsaKey.Gen()
+}
+
+func newGenerator(t *testing.T, clock Clock) *generator.Generator {
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithClock(clock))
+	_, _, err := g.CreateWithOptions(crt.NewCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	return g
+}
+
+func TestProvider(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	g := newGenerator(t, clock)
+	start := clock.Now()
+	var renewed int
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour)),
+		WithClock(clock),
+		WithRenewalFraction(0.25),
+		WithOnRenew(func(*tls.Certificate) { renewed++ }),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	assert.Equal(t, start.Add(45*time.Minute), p.RenewAt())
+
+	first, err := p.Certificate()
+	assert.NoError(t, err)
+	assert.NotNil(t, first.Leaf)
+
+	clock.Advance(44 * time.Minute)
+	cert, err := p.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Same(t, first, cert)
+	assert.Equal(t, 1, renewed)
+
+	clock.Advance(time.Minute)
+	cert, err = p.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, cert)
+	assert.NotEqual(t, first.Leaf.SerialNumber, cert.Leaf.SerialNumber)
+	assert.Equal(t, 2, renewed)
+	assert.Equal(t, clock.Now().Add(45*time.Minute), p.RenewAt())
+
+	assert.NoError(t, p.Renew())
+	assert.Equal(t, 3, renewed)
+}
+
+func TestProviderRenewalFailure(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	g := newGenerator(t, clock)
+	var errs int
+	var p *Provider
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour)),
+		WithClock(clock),
+		WithOnError(func(error) {
+			errs++
+			_ = p.RenewAt() // the lock is not held
+		}),
+	)
+	assert.NoError(t, err)
+	first, _ := p.Certificate()
+
+	// the renewal fails without a CA
+	g.SetCA(nil, nil)
+	clock.Advance(50 * time.Minute)
+	cert, err := p.Certificate()
+	assert.NoError(t, err)
+	assert.Same(t, first, cert)
+	assert.Equal(t, 1, errs)
+
+	// the renewal is not retried until the check interval has passed
+	_, err = p.Certificate()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, errs)
+
+	clock.Advance(10 * time.Minute)
+	_, err = p.Certificate()
+	assert.Equal(t, "provider: certificate has expired and cannot be renewed: x509: CA certificate or private key is not provided", err.Error())
+
+	t.Run("should report the error: Run with the expired certificate", func(t *testing.T) {
+		reported := make(chan error, 1)
+		p.onError = func(err error) {
+			select {
+			case reported <- err:
+			default:
+			}
+		}
+		p.interval = time.Millisecond
+		clock.Advance(time.Minute) // the failed renewal is retried after the check interval
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = p.Run(ctx) }()
+
+		select {
+		case err := <-reported:
+			assert.ErrorContains(t, err, "certificate has expired")
+		case <-time.After(5 * time.Second):
+			t.Fatal("error is not reported")
+		}
+	})
+
+	t.Run("should return error: the first certificate cannot be issued", func(t *testing.T) {
+		_, err := New(g, crt.NewServerCert())
+		assert.Error(t, err)
+	})
+}
+
+func TestProviderBackdate(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	g := newGenerator(t, clock)
+	start := clock.Now()
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour), crt.WithBackdate(20*time.Minute)),
+		WithClock(clock),
+		WithRenewalFraction(0.25),
+	)
+	assert.NoError(t, err)
+	// a quarter of 80 minutes before the NotAfter
+	assert.Equal(t, start.Add(40*time.Minute), p.RenewAt())
+
+	first, _ := p.Certificate()
+	assert.Equal(t, start.Add(time.Hour), first.Leaf.NotAfter)
+
+	// the renewal fails after the NotAfter, even if the backdated validity has not passed
+	g.SetCA(nil, nil)
+	clock.Advance(time.Hour)
+	_, err = p.Certificate()
+	assert.ErrorContains(t, err, "certificate has expired")
+}
+
+func TestProviderGeneratorClock(t *testing.T) {
+	// the Generator is far in the past, the Provider follows its Clock by default
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := newGenerator(t, clock)
+	var renewed int
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour), crt.WithSerialNumber(big.NewInt(42))),
+		WithOnRenew(func(*tls.Certificate) { renewed++ }),
+	)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = p.Certificate()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, renewed)
+	assert.Equal(t, clock.Now().Add(40*time.Minute), p.RenewAt())
+}
+
+func TestProviderRenewalDue(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	g := newGenerator(t, clock)
+
+	// the renewal of a certificate backdated by 2 hours is due immediately
+	_, err := New(g, crt.NewServerCert(crt.WithNotAfter(clock.Now().Add(time.Hour)), crt.WithBackdate(2*time.Hour)))
+	assert.ErrorContains(t, err, "provider: renewal of the new certificate is due at")
+}
+
+func TestProviderRenewalUnlocked(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	keyG := &blockingKey{EcdsaKey: key.NewEcdsaKey(nil)}
+	g := newGenerator(t, clock)
+	ca, caKey := g.CA()
+	g = generator.New(generator.WithKeyGenerator(keyG), generator.WithClock(clock), generator.WithCA(ca, caKey))
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour)))
+	assert.NoError(t, err)
+	first, _ := p.Certificate()
+
+	keyG.block()
+	clock.Advance(50 * time.Minute)
+	done := make(chan *tls.Certificate)
+	go func() {
+		cert, _ := p.Certificate()
+		done <- cert
+	}()
+	<-keyG.started
+
+	// the current certificate is served during the renewal
+	assert.Equal(t, clock.Now().Add(-10*time.Minute), p.RenewAt())
+	cert, err := p.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Same(t, first, cert)
+
+	keyG.release()
+	assert.NotSame(t, first, <-done)
+}
+
+func TestProviderRun(t *testing.T) {
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	g := newGenerator(t, clock)
+	renewed := make(chan struct{}, 1)
+
+	p, err := New(g, crt.NewServerCert(crt.WithValidity(time.Hour)),
+		WithClock(clock),
+		WithCheckInterval(time.Millisecond),
+		WithOnRenew(func(*tls.Certificate) {
+			select {
+			case renewed <- struct{}{}:
+			default:
+			}
+		}),
+	)
+	assert.NoError(t, err)
+	<-renewed
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- p.Run(ctx) }()
+
+	clock.Advance(time.Hour)
+	select {
+	case <-renewed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate is not renewed")
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
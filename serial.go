@@ -0,0 +1,126 @@
+package crt
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// _serialNumberLimit is the upper bound of the random serial numbers, 128 bits
+// exceed the 64 bits of CSPRNG output required by the CA/Browser Forum Baseline
+// Requirements, and the DER encoding never exceeds 20 octets.
+var _serialNumberLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// SerialNumberGenerator generates the serial numbers of certificates.
+// The serial numbers must be positive.
+type SerialNumberGenerator interface {
+	Next() (*big.Int, error)
+}
+
+// RandomSerialNumber generates random 128-bit serial numbers.
+type RandomSerialNumber struct{}
+
+// NewRandomSerialNumber returns a random serial number generator, it is the
+// default SerialNumberGenerator of a Certificate.
+func NewRandomSerialNumber() *RandomSerialNumber {
+	return &RandomSerialNumber{}
+}
+
+// Next implements SerialNumberGenerator interface.
+func (g *RandomSerialNumber) Next() (*big.Int, error) {
+	for {
+		n, err := rand.Int(rand.Reader, _serialNumberLimit)
+		if err != nil {
+			return nil, err
+		}
+		if n.Sign() > 0 {
+			return n, nil
+		}
+	}
+}
+
+// SequentialSerialNumber generates sequential serial numbers. If the counter
+// file is set, the last serial number is persisted to it, so the sequence
+// continues across processes. A SequentialSerialNumber is safe for concurrent use.
+type SequentialSerialNumber struct {
+	mu    sync.Mutex
+	last  *big.Int
+	fpath string
+}
+
+// NewSequentialSerialNumber returns a sequential serial number generator
+// that starts at the given number, if start is nil or not positive, it starts at 1.
+func NewSequentialSerialNumber(start *big.Int) *SequentialSerialNumber {
+	last := big.NewInt(0)
+	if start != nil && start.Sign() > 0 {
+		last.Sub(start, big.NewInt(1))
+	}
+	return &SequentialSerialNumber{last: last}
+}
+
+// NewFileSerialNumber returns a sequential serial number generator that
+// persists the last serial number to the given counter file in decimal form.
+// If the file does not exist, the sequence starts at 1.
+func NewFileSerialNumber(fpath string) (*SequentialSerialNumber, error) {
+	last := big.NewInt(0)
+	data, err := os.ReadFile(fpath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if s := strings.TrimSpace(string(data)); len(s) > 0 {
+		if _, ok := last.SetString(s, 10); !ok || last.Sign() < 0 {
+			return nil, fmt.Errorf("crt: invalid serial number %q in %s", s, fpath)
+		}
+	}
+	return &SequentialSerialNumber{last: last, fpath: fpath}, nil
+}
+
+// Next implements SerialNumberGenerator interface.
+func (g *SequentialSerialNumber) Next() (*big.Int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	next := new(big.Int).Add(g.last, big.NewInt(1))
+	if len(g.fpath) > 0 {
+		if err := writeFileAtomic(g.fpath, []byte(next.String()+"\n")); err != nil {
+			return nil, err
+		}
+	}
+	g.last = next
+	return new(big.Int).Set(next), nil
+}
+
+// fixedSerialNumber always returns the same serial number.
+type fixedSerialNumber struct {
+	n *big.Int
+}
+
+func (g fixedSerialNumber) Next() (*big.Int, error) {
+	if g.n == nil || g.n.Sign() <= 0 {
+		return nil, errors.New("crt: serial number must be positive")
+	}
+	return new(big.Int).Set(g.n), nil
+}
+
+// writeFileAtomic writes the data to a temporary file, then renames it to the given path.
+func writeFileAtomic(fpath string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(fpath), filepath.Base(fpath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fpath)
+}
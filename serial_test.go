@@ -0,0 +1,152 @@
+package crt_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+func TestRandomSerialNumber(t *testing.T) {
+	tmpl, err := New().Template()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tmpl.SerialNumber.Sign())
+	assert.LessOrEqual(t, tmpl.SerialNumber.BitLen(), 128)
+
+	seen := make(map[string]struct{})
+	g := NewRandomSerialNumber()
+	for i := 0; i < 100; i++ {
+		n, err := g.Next()
+		assert.NoError(t, err)
+		seen[n.String()] = struct{}{}
+	}
+	assert.Equal(t, 100, len(seen))
+}
+
+func TestSequentialSerialNumber(t *testing.T) {
+	t.Run("start", func(t *testing.T) {
+		g := NewSequentialSerialNumber(big.NewInt(1000))
+		for _, expected := range []int64{1000, 1001, 1002} {
+			n, err := g.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, n.Int64())
+		}
+		n, _ := NewSequentialSerialNumber(nil).Next()
+		assert.Equal(t, int64(1), n.Int64())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		g := NewSequentialSerialNumber(nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = g.Next()
+			}()
+		}
+		wg.Wait()
+		n, _ := g.Next()
+		assert.Equal(t, int64(11), n.Int64())
+	})
+}
+
+func TestFileSerialNumber(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "serial")
+
+	g, err := NewFileSerialNumber(fpath)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = g.Next()
+		assert.NoError(t, err)
+	}
+	data, err := os.ReadFile(fpath)
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", string(data))
+
+	t.Run("continue the sequence", func(t *testing.T) {
+		g, err := NewFileSerialNumber(fpath)
+		assert.NoError(t, err)
+		n, err := g.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), n.Int64())
+	})
+
+	t.Run("should return error: invalid counter", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "serial")
+		assert.NoError(t, os.WriteFile(bad, []byte("foo"), 0o600))
+		_, err := NewFileSerialNumber(bad)
+		assert.Contains(t, err.Error(), `crt: invalid serial number "foo"`)
+	})
+}
+
+func TestWithSerialNumber(t *testing.T) {
+	c := New(WithSerialNumber(big.NewInt(42)))
+	assert.True(t, c.HasSerialNumber())
+	assert.False(t, New().HasSerialNumber())
+
+	tmpl, err := c.Template()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), tmpl.SerialNumber.Int64())
+
+	_, err = New(WithSerialNumber(big.NewInt(0))).Template()
+	assert.EqualError(t, err, "crt: serial number must be positive")
+
+	t.Run("Gen keeps generating a serial number", func(t *testing.T) {
+		//nolint:staticcheck // Gen is deprecated
+		assert.Equal(t, int64(42), c.Gen().SerialNumber.Int64())
+
+		// the errors are ignored, a random serial number is used
+		//nolint:staticcheck // Gen is deprecated
+		tmpl := New(WithSerialNumber(big.NewInt(0)), WithNotAfter(time.Now().Add(-time.Hour))).Gen()
+		assert.NotNil(t, tmpl.SerialNumber)
+		assert.Positive(t, tmpl.SerialNumber.Sign())
+	})
+}
+
+func TestGeneratorSerialNumber(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+
+	t.Run("should return error: duplicate serial number", func(t *testing.T) {
+		c := NewServerCert(WithSerialNumber(big.NewInt(42)))
+		_, _, err := g.Create(c)
+		assert.NoError(t, err)
+		_, _, err = g.Create(c)
+		assert.EqualError(t, err, "x509: serial number 42 has already been issued by the CA")
+	})
+
+	t.Run("different CA", func(t *testing.T) {
+		other := createEcdsaGenWithCA(t)
+		_, _, err := other.Create(NewServerCert(WithSerialNumber(big.NewInt(42))))
+		assert.NoError(t, err)
+	})
+
+	t.Run("generator serial number generator", func(t *testing.T) {
+		sg := generator.New(generator.WithSerialNumberGenerator(NewSequentialSerialNumber(big.NewInt(100))))
+		ca, caKey := g.CA()
+		sg.SetCA(ca, caKey)
+
+		for _, expected := range []int64{100, 101} {
+			cert, _, err := sg.CreateWithOptions(NewClientCert(), generator.CreateOptions{G: key.NewEcdsaKey(nil)})
+			assert.NoError(t, err)
+			parsed, err := generator.ParseCertificate(cert)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, parsed.SerialNumber.Int64())
+		}
+
+		// the serial number of the template takes precedence
+		cert, _, err := sg.CreateWithOptions(NewClientCert(WithSerialNumber(big.NewInt(7))), generator.CreateOptions{G: key.NewEcdsaKey(nil)})
+		assert.NoError(t, err)
+		parsed, err := generator.ParseCertificate(cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), parsed.SerialNumber.Int64())
+	})
+}
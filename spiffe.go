@@ -0,0 +1,65 @@
+package crt
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+const _spiffeScheme = "spiffe"
+
+// ParseSPIFFEID parses and validates a SPIFFE ID, e.g. "spiffe://example.org/workload",
+// see https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE-ID.md.
+func ParseSPIFFEID(id string) (*url.URL, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateSPIFFEID(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// validateSPIFFEID validates the given SPIFFE ID.
+func validateSPIFFEID(u *url.URL) error {
+	if u == nil {
+		return errors.New("crt: SPIFFE ID is not provided")
+	}
+	if u.Scheme != _spiffeScheme {
+		return errors.New("crt: SPIFFE ID scheme must be spiffe")
+	}
+	if u.User != nil || u.Port() != "" || u.RawQuery != "" || u.Fragment != "" || u.Opaque != "" {
+		return errors.New("crt: SPIFFE ID must not contain user info, port, query or fragment")
+	}
+	if u.Host == "" {
+		return errors.New("crt: SPIFFE ID trust domain is empty")
+	}
+	for _, r := range u.Host {
+		if !isSPIFFETrustDomainChar(r) {
+			return errors.New("crt: SPIFFE ID trust domain contains invalid characters")
+		}
+	}
+	if u.Path != "" {
+		for _, seg := range strings.Split(u.Path[1:], "/") {
+			if seg == "" || seg == "." || seg == ".." {
+				return errors.New("crt: SPIFFE ID path contains empty, dot or dot-dot segments")
+			}
+			for _, r := range seg {
+				if !isSPIFFEPathChar(r) {
+					return errors.New("crt: SPIFFE ID path contains invalid characters")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isSPIFFETrustDomainChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_'
+}
+
+func isSPIFFEPathChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		r == '.' || r == '-' || r == '_'
+}
@@ -0,0 +1,95 @@
+package crt_test
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+)
+
+func TestParseSPIFFEID(t *testing.T) {
+	valid := []string{
+		"spiffe://example.org",
+		"spiffe://example.org/ns/default/sa/web",
+		"spiffe://cluster-1.example_org/a.b-c_d",
+	}
+	for _, v := range valid {
+		t.Run(v, func(t *testing.T) {
+			id, err := ParseSPIFFEID(v)
+			assert.NoError(t, err)
+			assert.Equal(t, v, id.String())
+		})
+	}
+
+	invalid := []string{
+		"https://example.org/web",
+		"spiffe:///web",
+		"spiffe://Example.org/web",
+		"spiffe://example.org:8080/web",
+		"spiffe://user@example.org/web",
+		"spiffe://example.org/web?x=1",
+		"spiffe://example.org/web#x",
+		"spiffe://example.org/",
+		"spiffe://example.org/a//b",
+		"spiffe://example.org/a/../b",
+		"spiffe://example.org/a%20b",
+	}
+	for _, v := range invalid {
+		t.Run("should return error: "+v, func(t *testing.T) {
+			_, err := ParseSPIFFEID(v)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewSVIDCert(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	id, err := ParseSPIFFEID("spiffe://example.org/ns/default/sa/web")
+	assert.NoError(t, err)
+	other, err := ParseSPIFFEID("spiffe://example.org/other")
+	assert.NoError(t, err)
+
+	cert := NewSVIDCert(id, WithURIs(other))
+	assert.True(t, cert.IsServerCert())
+	assert.True(t, cert.IsClientCert())
+
+	created, _, err := g.Create(cert)
+	assert.NoError(t, err)
+	parsed, err := parseCertBytes(created)
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.Subject.CommonName)
+	assert.Equal(t, 1, len(parsed.URIs))
+	assert.Equal(t, id.String(), parsed.URIs[0].String())
+	assert.Empty(t, parsed.DNSNames)
+	assert.False(t, parsed.IsCA)
+	assert.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, parsed.KeyUsage)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, parsed.ExtKeyUsage)
+}
+
+func TestInvalidSVIDCert(t *testing.T) {
+	invalid := []struct {
+		title    string
+		id       *url.URL
+		expected string
+	}{
+		{"nil", nil, "crt: SPIFFE ID is not provided"},
+		{"scheme", &url.URL{Scheme: "https", Host: "example.org", Path: "/web"}, "crt: SPIFFE ID scheme must be spiffe"},
+		{"user", &url.URL{Scheme: "spiffe", User: url.User("foo"), Host: "example.org"}, "crt: SPIFFE ID must not contain user info, port, query or fragment"},
+		{"query", &url.URL{Scheme: "spiffe", Host: "example.org", RawQuery: "x=1"}, "crt: SPIFFE ID must not contain user info, port, query or fragment"},
+		{"fragment", &url.URL{Scheme: "spiffe", Host: "example.org", Fragment: "x"}, "crt: SPIFFE ID must not contain user info, port, query or fragment"},
+		{"trust domain", &url.URL{Scheme: "spiffe", Path: "/web"}, "crt: SPIFFE ID trust domain is empty"},
+	}
+	for _, v := range invalid {
+		t.Run("should return error: "+v.title, func(t *testing.T) {
+			_, err := NewSVIDCert(v.id).Template()
+			assert.EqualError(t, err, v.expected)
+		})
+	}
+
+	g := createEcdsaGenWithCA(t)
+	_, _, err := g.Create(NewSVIDCert(nil))
+	assert.EqualError(t, err, "crt: SPIFFE ID is not provided")
+}
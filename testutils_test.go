@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -13,6 +14,7 @@ import (
 
 	. "github.com/shipengqi/crt"
 	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -88,7 +90,7 @@ func parseKeyBytes(key []byte) (crypto.PrivateKey, error) {
 	var pkcs8 interface{}
 	if pkcs8, err = x509.ParsePKCS8PrivateKey(keyBytes); err == nil {
 		switch pkcs8k := pkcs8.(type) {
-		case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
 			return pkcs8k, nil
 		default:
 			return nil, errors.New("unknown private key type in PKCS#8 wrapping")
@@ -136,3 +138,21 @@ func createGenWithUseAsCA(t *testing.T) *generator.Generator {
 	assert.Nil(t, err)
 	return g
 }
+
+func createEcdsaGenWithCA(t *testing.T) *generator.Generator {
+	t.Helper()
+
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+	_, _, err := g.CreateWithOptions(NewCACert(), generator.CreateOptions{
+		UseAsCA: true,
+	})
+	assert.Nil(t, err)
+	return g
+}
+
+func mustTemplate(t *testing.T, c *Certificate) *x509.Certificate {
+	t.Helper()
+	tmpl, err := c.Template()
+	assert.NoError(t, err)
+	return tmpl
+}
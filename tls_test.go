@@ -0,0 +1,77 @@
+package crt_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+)
+
+func TestTLSConfig(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+
+	serverConfig, err := g.ServerTLSConfig(NewServerCert(WithIPs(net.ParseIP("127.0.0.1"))))
+	assert.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+	assert.Equal(t, uint16(tls.VersionTLS12), serverConfig.MinVersion)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = serverConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		clientConfig, err := g.ClientTLSConfig(NewClientCert(WithCN("test client")))
+		assert.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+		resp, err := client.Get(srv.URL)
+		assert.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "test client", string(body))
+	})
+
+	t.Run("should return error: no client certificate", func(t *testing.T) {
+		clientConfig, err := g.ClientTLSConfig(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, clientConfig.Certificates)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+		_, err = client.Get(srv.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error: CA certificate or private key is not provided", func(t *testing.T) {
+		g := generator.New()
+		_, err := g.CertPool()
+		assert.Equal(t, "x509: CA certificate or private key is not provided", err.Error())
+		_, err = g.ServerTLSConfig(NewServerCert())
+		assert.Error(t, err)
+		_, err = g.ClientTLSConfig(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTLSCertificate(t *testing.T) {
+	g := createEcdsaGenWithCA(t)
+	_, _, err := g.CreateWithOptions(NewIntermediateCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+
+	cert, err := g.TLSCertificate(NewServerCert(WithDNSNames("example.com")))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(cert.Certificate))
+	assert.NotNil(t, cert.Leaf)
+	assert.Equal(t, []string{"example.com"}, cert.Leaf.DNSNames)
+	assert.Equal(t, "CRT GENERATOR INTERMEDIATE CA", cert.Leaf.Issuer.CommonName)
+}
@@ -0,0 +1,92 @@
+package crt_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestValidityPeriod(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := fixedClock(now)
+
+	tests := []struct {
+		title     string
+		opts      []Option
+		notBefore time.Time
+		notAfter  time.Time
+	}{
+		{"clock", []Option{WithClock(clock), WithValidity(time.Hour)}, now, now.Add(time.Hour)},
+		{"backdate", []Option{WithClock(clock), WithValidity(time.Hour), WithBackdate(5 * time.Minute)},
+			now.Add(-5 * time.Minute), now.Add(time.Hour)},
+		{"not before", []Option{WithClock(clock), WithValidity(time.Hour), WithNotBefore(now.Add(24 * time.Hour))},
+			now.Add(24 * time.Hour), now.Add(25 * time.Hour)},
+		{"not after", []Option{WithClock(clock), WithNotAfter(now.Add(time.Minute))}, now, now.Add(time.Minute)},
+		{"already expired", []Option{WithNotBefore(now.Add(-7 * 24 * time.Hour)), WithNotAfter(now.Add(-24 * time.Hour))},
+			now.Add(-7 * 24 * time.Hour), now.Add(-24 * time.Hour)},
+	}
+	for _, v := range tests {
+		t.Run(v.title, func(t *testing.T) {
+			tmpl, err := New(v.opts...).Template()
+			assert.NoError(t, err)
+			assert.Equal(t, v.notBefore, tmpl.NotBefore)
+			assert.Equal(t, v.notAfter, tmpl.NotAfter)
+		})
+	}
+
+	t.Run("template at", func(t *testing.T) {
+		tmpl, err := New(WithValidity(time.Hour)).TemplateAt(now)
+		assert.NoError(t, err)
+		assert.Equal(t, now, tmpl.NotBefore)
+		assert.Equal(t, now.Add(time.Hour), tmpl.NotAfter)
+	})
+
+	t.Run("should return error: NotAfter is before NotBefore", func(t *testing.T) {
+		_, err := New(WithNotBefore(now), WithNotAfter(now.Add(-time.Hour))).Template()
+		assert.EqualError(t, err, "crt: NotAfter 2024-05-01T11:00:00Z is before NotBefore 2024-05-01T12:00:00Z")
+	})
+}
+
+func TestGeneratorClock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithClock(fixedClock(now)))
+	_, _, err := g.CreateWithOptions(NewCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	ca, _ := g.CA()
+	assert.Equal(t, now, ca.NotBefore)
+
+	t.Run("generator clock", func(t *testing.T) {
+		cert, _, err := g.Create(NewServerCert(WithValidity(time.Hour)))
+		assert.NoError(t, err)
+		parsed, err := generator.ParseCertificate(cert)
+		assert.NoError(t, err)
+		assert.Equal(t, now, parsed.NotBefore)
+		assert.Equal(t, now.Add(time.Hour), parsed.NotAfter)
+	})
+
+	t.Run("certificate clock takes precedence", func(t *testing.T) {
+		later := now.Add(time.Hour)
+		cert, _, err := g.Create(NewServerCert(WithClock(fixedClock(later))))
+		assert.NoError(t, err)
+		parsed, err := generator.ParseCertificate(cert)
+		assert.NoError(t, err)
+		assert.Equal(t, later, parsed.NotBefore)
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		assert.NoError(t, g.Revoke(big.NewInt(1), 0, time.Time{}))
+		r, ok := g.IsRevoked(big.NewInt(1))
+		assert.True(t, ok)
+		assert.Equal(t, now, r.RevokedAt)
+	})
+}
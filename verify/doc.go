@@ -0,0 +1,3 @@
+// Package verify builds and validates the certificate chains of the issued
+// certificates, and reports the typed reasons of the failures.
+package verify
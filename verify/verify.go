@@ -0,0 +1,257 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shipengqi/crt/generator"
+)
+
+// Reason is the reason of a verification failure.
+type Reason int
+
+// The reasons of the verification failures.
+const (
+	// ReasonUnknown is an unclassified failure, see Error.Err for the details.
+	ReasonUnknown Reason = iota
+	// ReasonMalformed results when the given data cannot be parsed.
+	ReasonMalformed
+	// ReasonExpired results when a certificate in the chain has expired.
+	ReasonExpired
+	// ReasonNotYetValid results when a certificate in the chain is not valid yet.
+	ReasonNotYetValid
+	// ReasonUnknownAuthority results when no chain to the roots can be built.
+	ReasonUnknownAuthority
+	// ReasonIncompatibleUsage results when the certificate chain does not
+	// permit the requested extended key usages.
+	ReasonIncompatibleUsage
+	// ReasonNameMismatch results when the leaf certificate is not valid for
+	// the requested host name.
+	ReasonNameMismatch
+	// ReasonConstraintViolation results when the names of the leaf certificate
+	// violate the name constraints of a CA certificate.
+	ReasonConstraintViolation
+	// ReasonNotAuthorizedToSign results when a certificate is signed by
+	// a certificate that is not a CA certificate.
+	ReasonNotAuthorizedToSign
+	// ReasonTooManyIntermediates results when a path length constraint is violated.
+	ReasonTooManyIntermediates
+	// ReasonUnhandledCriticalExtension results when a certificate in the
+	// chain has an unknown critical extension.
+	ReasonUnhandledCriticalExtension
+)
+
+var _reasonNames = map[Reason]string{
+	ReasonUnknown:                    "unknown",
+	ReasonMalformed:                  "malformed",
+	ReasonExpired:                    "expired",
+	ReasonNotYetValid:                "not yet valid",
+	ReasonUnknownAuthority:           "unknown authority",
+	ReasonIncompatibleUsage:          "incompatible usage",
+	ReasonNameMismatch:               "name mismatch",
+	ReasonConstraintViolation:        "constraint violation",
+	ReasonNotAuthorizedToSign:        "not authorized to sign",
+	ReasonTooManyIntermediates:       "too many intermediates",
+	ReasonUnhandledCriticalExtension: "unhandled critical extension",
+}
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	if name, ok := _reasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// Error is the error returned by Verify.
+// Cert is the certificate that fails the verification, it can be nil.
+// Err is the underlying error, typically an error of the crypto/x509 package.
+type Error struct {
+	Reason Reason
+	Cert   *x509.Certificate
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Cert != nil {
+		return fmt.Sprintf("verify: %s: certificate %q: %v", e.Reason, e.Cert.Subject.String(), e.Err)
+	}
+	return fmt.Sprintf("verify: %s: %v", e.Reason, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf returns the Reason of the given error, if the error is not
+// an *Error, ReasonUnknown is returned.
+func ReasonOf(err error) Reason {
+	var verr *Error
+	if errors.As(err, &verr) {
+		return verr.Reason
+	}
+	return ReasonUnknown
+}
+
+// Options defines options for Verify.
+// Usages are the acceptable extended key usages, if empty,
+// x509.ExtKeyUsageServerAuth is used, x509.ExtKeyUsageAny accepts any usage.
+// DNSName is the host name or IP address to check, it is not checked if empty.
+// Time is the time to check the validity of the chain, if zero, time.Now() is used.
+type Options struct {
+	Usages  []x509.ExtKeyUsage
+	DNSName string
+	Time    time.Time
+}
+
+// Verify parses the leaf certificate, the intermediate and root certificates
+// from the given PEM bundles, then builds and validates the chains from the
+// leaf certificate to the roots. The certificates that follow the leaf
+// certificate in the leaf bundle, e.g. the CA certificates appended by
+// generator.CreateOptions.AppendCA, are used as intermediates.
+// The intermediates can be nil, the roots are required.
+// It returns the verified chains, or an *Error.
+func Verify(leaf, intermediates, roots []byte, opts Options) ([][]*x509.Certificate, error) {
+	leafs, err := parseCertificates("leaf", leaf)
+	if err != nil {
+		return nil, err
+	}
+	inters, err := parseCertificates("intermediate", intermediates)
+	if err != nil {
+		return nil, err
+	}
+	rootCerts, err := parseCertificates("root", roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(leafs) == 0 {
+		return nil, &Error{Reason: ReasonMalformed, Err: errors.New("no leaf certificate is found")}
+	}
+
+	return VerifyCertificate(leafs[0], append(leafs[1:], inters...), rootCerts, opts)
+}
+
+// VerifyCertificate is the same as Verify, but takes parsed certificates.
+func VerifyCertificate(leaf *x509.Certificate, intermediates, roots []*x509.Certificate, opts Options) ([][]*x509.Certificate, error) {
+	if len(roots) == 0 {
+		return nil, &Error{Reason: ReasonMalformed, Err: errors.New("no root certificate is found")}
+	}
+	rootPool := x509.NewCertPool()
+	for _, cert := range roots {
+		rootPool.AddCert(cert)
+	}
+	interPool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		interPool.AddCert(cert)
+	}
+
+	vopts := x509.VerifyOptions{
+		DNSName:       opts.DNSName,
+		Intermediates: interPool,
+		Roots:         rootPool,
+		CurrentTime:   opts.Time,
+		KeyUsages:     opts.Usages,
+	}
+	chains, err := leaf.Verify(vopts)
+	if err != nil {
+		candidates := make([]*x509.Certificate, 0, len(intermediates)+len(roots))
+		candidates = append(candidates, intermediates...)
+		candidates = append(candidates, roots...)
+		return nil, classify(err, leaf, candidates, opts.Time)
+	}
+	return chains, nil
+}
+
+// classify converts the error of x509.Certificate.Verify to an *Error.
+// The candidates are the intermediate and root certificates.
+func classify(err error, leaf *x509.Certificate, candidates []*x509.Certificate, at time.Time) *Error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var (
+		invalid   x509.CertificateInvalidError
+		hostname  x509.HostnameError
+		authority x509.UnknownAuthorityError
+		critical  x509.UnhandledCriticalExtension
+		violation x509.ConstraintViolationError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		verr := &Error{Reason: ReasonUnknown, Cert: invalid.Cert, Err: err}
+		switch invalid.Reason {
+		case x509.Expired:
+			verr.Reason = ReasonExpired
+			if invalid.Cert != nil && at.Before(invalid.Cert.NotBefore) {
+				verr.Reason = ReasonNotYetValid
+			}
+		case x509.NotAuthorizedToSign:
+			verr.Reason = ReasonNotAuthorizedToSign
+		case x509.CANotAuthorizedForThisName, x509.UnconstrainedName, x509.TooManyConstraints:
+			verr.Reason = ReasonConstraintViolation
+		case x509.TooManyIntermediates:
+			verr.Reason = ReasonTooManyIntermediates
+		case x509.IncompatibleUsage, x509.CANotAuthorizedForExtKeyUsage:
+			verr.Reason = ReasonIncompatibleUsage
+		}
+		return verr
+	case errors.As(err, &hostname):
+		return &Error{Reason: ReasonNameMismatch, Cert: hostname.Certificate, Err: err}
+	case errors.As(err, &authority):
+		cert := authority.Cert
+		if cert == nil {
+			cert = leaf
+		}
+		// x509.Certificate.Verify hides the reason of a rejected candidate issuer,
+		// a candidate that is not a CA is reported as ReasonNotAuthorizedToSign.
+		if issuer := notAuthorizedIssuer(cert, candidates); issuer != nil {
+			return &Error{Reason: ReasonNotAuthorizedToSign, Cert: issuer, Err: err}
+		}
+		return &Error{Reason: ReasonUnknownAuthority, Cert: cert, Err: err}
+	case errors.As(err, &critical):
+		return &Error{Reason: ReasonUnhandledCriticalExtension, Cert: leaf, Err: err}
+	case errors.As(err, &violation):
+		verr := &Error{Reason: ReasonNotAuthorizedToSign, Cert: leaf, Err: err}
+		for _, cert := range append([]*x509.Certificate{leaf}, candidates...) {
+			if issuer := notAuthorizedIssuer(cert, candidates); issuer != nil {
+				verr.Cert = issuer
+				break
+			}
+		}
+		return verr
+	}
+	return &Error{Reason: ReasonUnknown, Cert: leaf, Err: err}
+}
+
+// notAuthorizedIssuer returns the candidate that issued the given certificate,
+// but is not allowed to sign certificates.
+func notAuthorizedIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if !bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
+			continue
+		}
+		var violation x509.ConstraintViolationError
+		if err := cert.CheckSignatureFrom(candidate); errors.As(err, &violation) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// parseCertificates parses all the certificates from the given PEM data by
+// generator.ParseCertificates, the empty data has no certificates.
+func parseCertificates(kind string, data []byte) ([]*x509.Certificate, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	certs, err := generator.ParseCertificates(data)
+	if err != nil {
+		return nil, &Error{Reason: ReasonMalformed, Err: fmt.Errorf("%s certificate: %w", kind, err)}
+	}
+	return certs, nil
+}
@@ -0,0 +1,144 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shipengqi/crt"
+	"github.com/shipengqi/crt/generator"
+	"github.com/shipengqi/crt/key"
+)
+
+const certificateBlockType = "CERTIFICATE"
+
+func newGenerator(t *testing.T, ca *crt.Certificate) (*generator.Generator, []byte) {
+	g := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)))
+	root, _, err := g.CreateWithOptions(ca, generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	return g, root
+}
+
+// issueWithKey signs the template with the CA of the Generator directly,
+// without the checks of the Generator.
+func issueWithKey(t *testing.T, g *generator.Generator, c *crt.Certificate) ([]byte, crypto.Signer) {
+	ca, caKey := g.CA()
+	tmpl, err := c.Template()
+	assert.NoError(t, err)
+	signer, err := key.NewEcdsaKey(nil).Gen()
+	assert.NoError(t, err)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, signer.Public(), caKey.(crypto.Signer))
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: certificateBlockType, Bytes: der}), signer
+}
+
+func TestVerify(t *testing.T) {
+	g, root := newGenerator(t, crt.NewCACert())
+	_, _, err := g.CreateWithOptions(crt.NewIntermediateCACert(), generator.CreateOptions{UseAsCA: true})
+	assert.NoError(t, err)
+	leaf, _, err := g.CreateWithOptions(crt.NewServerCert(crt.WithDNSNames("example.com")), generator.CreateOptions{AppendCA: true})
+	assert.NoError(t, err)
+
+	t.Run("verified", func(t *testing.T) {
+		chains, err := Verify(leaf, nil, root, Options{DNSName: "example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(chains[0]))
+		assert.Equal(t, "CRT GENERATOR CA", chains[0][2].Subject.CommonName)
+	})
+
+	tests := []struct {
+		title    string
+		opts     Options
+		roots    []byte
+		expected Reason
+	}{
+		{"expired", Options{Time: time.Now().Add(2 * 365 * 24 * time.Hour)}, root, ReasonExpired},
+		{"not yet valid", Options{Time: time.Now().Add(-24 * time.Hour)}, root, ReasonNotYetValid},
+		{"name mismatch", Options{DNSName: "example.org"}, root, ReasonNameMismatch},
+		{"incompatible usage", Options{Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, root, ReasonIncompatibleUsage},
+		{"malformed roots", Options{}, nil, ReasonMalformed},
+	}
+	for _, v := range tests {
+		t.Run("should return error: "+v.title, func(t *testing.T) {
+			_, err := Verify(leaf, nil, v.roots, v.opts)
+			assert.Error(t, err)
+			assert.Equal(t, v.expected, ReasonOf(err))
+		})
+	}
+
+	t.Run("should return error: unknown authority", func(t *testing.T) {
+		_, other := newGenerator(t, crt.NewCACert())
+		_, err := Verify(leaf, nil, other, Options{})
+		assert.Equal(t, ReasonUnknownAuthority, ReasonOf(err))
+
+		var verr *Error
+		assert.True(t, errors.As(err, &verr))
+		assert.Equal(t, "CRT GENERATOR INTERMEDIATE CA", verr.Cert.Subject.CommonName)
+		var uerr x509.UnknownAuthorityError
+		assert.True(t, errors.As(err, &uerr))
+	})
+
+	t.Run("should return error: malformed leaf", func(t *testing.T) {
+		bad := pem.EncodeToMemory(&pem.Block{Type: certificateBlockType, Bytes: []byte("foo")})
+		_, err := Verify(bad, nil, root, Options{})
+		assert.Equal(t, ReasonMalformed, ReasonOf(err))
+		_, err = Verify(nil, nil, root, Options{})
+		assert.Equal(t, "verify: malformed: no leaf certificate is found", err.Error())
+	})
+}
+
+func TestVerifyConstraints(t *testing.T) {
+	t.Run("should return error: constraint violation", func(t *testing.T) {
+		g, root := newGenerator(t, crt.NewCACert(crt.WithPermittedDNSDomains("example.com")))
+		leaf, _ := issueWithKey(t, g, crt.NewServerCert(crt.WithDNSNames("example.org")))
+		_, err := Verify(leaf, nil, root, Options{})
+		assert.Equal(t, ReasonConstraintViolation, ReasonOf(err))
+	})
+
+	t.Run("should return error: too many intermediates", func(t *testing.T) {
+		g, root := newGenerator(t, crt.NewCACert(crt.WithMaxPathLen(0)))
+		inters, interKey := issueWithKey(t, g, crt.NewIntermediateCACert())
+		interCert, err := generator.ParseCertificate(inters)
+		assert.NoError(t, err)
+
+		g2 := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(interCert, interKey))
+		leaf, _, err := g2.Create(crt.NewServerCert())
+		assert.NoError(t, err)
+
+		_, err = Verify(leaf, inters, root, Options{})
+		assert.Equal(t, ReasonTooManyIntermediates, ReasonOf(err))
+	})
+
+	t.Run("should return error: not authorized to sign", func(t *testing.T) {
+		g, root := newGenerator(t, crt.NewCACert())
+		inters, interKey := issueWithKey(t, g, crt.NewServerCert(crt.WithCN("not a CA")))
+		notCA, err := generator.ParseCertificate(inters)
+		assert.NoError(t, err)
+
+		g2 := generator.New(generator.WithKeyGenerator(key.NewEcdsaKey(nil)), generator.WithCA(notCA, interKey))
+		leaf, _, err := g2.Create(crt.NewServerCert())
+		assert.NoError(t, err)
+
+		_, err = Verify(leaf, inters, root, Options{})
+		assert.Equal(t, ReasonNotAuthorizedToSign, ReasonOf(err))
+
+		// the issuer is reported for a x509.ConstraintViolationError
+		leafCert, err := generator.ParseCertificate(leaf)
+		assert.NoError(t, err)
+		verr := classify(x509.ConstraintViolationError{}, leafCert, []*x509.Certificate{notCA}, time.Time{})
+		assert.Equal(t, ReasonNotAuthorizedToSign, verr.Reason)
+		assert.Same(t, notCA, verr.Cert)
+	})
+}
+
+func TestReason(t *testing.T) {
+	assert.Equal(t, "constraint violation", ReasonConstraintViolation.String())
+	assert.Equal(t, "Reason(99)", Reason(99).String())
+	assert.Equal(t, ReasonUnknown, ReasonOf(errors.New("foo")))
+}